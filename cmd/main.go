@@ -16,7 +16,7 @@ func main() {
 
 	mux := http.NewServeMux()
 	routes.RegisterRoutes(mux, cfg)
-	handler := middleware.AppVersionMiddleware(cfg)(mux)
+	handler := middleware.ClientIPMiddleware(cfg)(middleware.AppVersionMiddleware(cfg)(mux))
 
 	// Optional: Serve static frontend
 	fileServer := http.FileServer(http.Dir("./web"))
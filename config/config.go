@@ -2,6 +2,7 @@ package config
 
 import (
 	"log"
+	"net"
 	"os"
 	"strconv"
 	"strings"
@@ -10,19 +11,31 @@ import (
 )
 
 type Config struct {
-	MongoURI              string
-	Database              string
-	JWTSecret             string
-	TokenExpiry           int
-	GoogleClientID        string
-	GoogleClientIDiOS     string
-	GoogleClientIDAndroid string
-	GoogleClientIDs       []string
-	DefaultSearchesLeft   int
-	MinAndroidVersionCode int
-	MaxAndroidVersionCode int
-	AndroidUpdateURL      string
-	RequireAppHeadersAuth bool
+	MongoURI               string
+	Database               string
+	JWTSecret              string
+	AccessTokenMinutes     int
+	RefreshTokenDays       int
+	JWTKeyRotationDays     int
+	TOTPIssuer             string
+	GoogleClientID         string
+	GoogleClientIDiOS      string
+	GoogleClientIDAndroid  string
+	GoogleClientIDs        []string
+	AppleClientIDs         []string // Services ID / bundle IDs; Sign in with Apple is off when empty
+	OIDCProviderName       string   // path name of the generic OIDC provider, e.g. "school"
+	OIDCIssuer             string
+	OIDCClientIDs          []string
+	OIDCJWKSURL            string // optional, discovered from the issuer when empty
+	OIDCLinkByEmail        bool   // lets a first OIDC sign-in take over an existing account with the same email
+	DefaultSearchesLeft    int
+	WordTrashRetentionDays int
+	AccountDeletionDays    int // grace period before a deleted account is purged
+	MinAndroidVersionCode  int
+	MaxAndroidVersionCode  int
+	AndroidUpdateURL       string
+	RequireAppHeadersAuth  bool
+	TrustedProxies         []*net.IPNet // X-Forwarded-For is only read from requests sent by these
 }
 
 func LoadConfig() *Config {
@@ -63,7 +76,54 @@ func LoadConfig() *Config {
 		}
 	}
 
-	googleClientIDs := buildGoogleClientIDList(
+	accessTokenMinutes := 15
+	if v := os.Getenv("ACCESS_TOKEN_MINUTES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			accessTokenMinutes = n
+		}
+	}
+
+	refreshTokenDays := 30
+	if v := os.Getenv("REFRESH_TOKEN_DAYS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			refreshTokenDays = n
+		}
+	}
+
+	wordTrashRetentionDays := 30
+	if v := os.Getenv("WORD_TRASH_RETENTION_DAYS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			wordTrashRetentionDays = n
+		}
+	}
+
+	accountDeletionDays := 14
+	if v := os.Getenv("ACCOUNT_DELETION_GRACE_DAYS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			accountDeletionDays = n
+		}
+	}
+
+	jwtKeyRotationDays := 30
+	if v := os.Getenv("JWT_KEY_ROTATION_DAYS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			jwtKeyRotationDays = n
+		}
+	}
+
+	// JWT_SECRET no longer signs tokens; it encrypts the signing keys stored in MongoDB.
+	jwtSecret := os.Getenv("JWT_SECRET")
+	if jwtSecret == "" {
+		log.Println("⚠️ JWT_SECRET is empty, signing keys will be stored with a predictable encryption key")
+	}
+
+	// Shown as the account name in authenticator apps.
+	totpIssuer := strings.TrimSpace(os.Getenv("TOTP_ISSUER"))
+	if totpIssuer == "" {
+		totpIssuer = "Japanese-Myanmar Dictionary"
+	}
+
+	googleClientIDs := buildClientIDList(
 		google,
 		googleIOS,
 		googleAndroid,
@@ -75,24 +135,61 @@ func LoadConfig() *Config {
 		log.Fatal("Missing Google OAuth client IDs. Set at least one of GOOGLE_CLIENT_ID / GOOGLE_CLIENT_ID_ANDROID / GOOGLE_CLIENT_IDS")
 	}
 
+	oidcProviderName := strings.ToLower(strings.TrimSpace(os.Getenv("OIDC_PROVIDER_NAME")))
+	if oidcProviderName == "" {
+		oidcProviderName = "oidc"
+	}
+
 	return &Config{
-		MongoURI:              uri,
-		Database:              db,
-		JWTSecret:             os.Getenv("JWT_SECRET"),
-		TokenExpiry:           72,
-		GoogleClientID:        google,
-		GoogleClientIDiOS:     googleIOS,
-		GoogleClientIDAndroid: googleAndroid,
-		GoogleClientIDs:       googleClientIDs,
-		DefaultSearchesLeft:   defaultSearches,
-		MinAndroidVersionCode: minAndroidVersionCode,
-		MaxAndroidVersionCode: maxAndroidVersionCode,
-		AndroidUpdateURL:      strings.TrimSpace(os.Getenv("ANDROID_UPDATE_URL")),
-		RequireAppHeadersAuth: isTruthy(os.Getenv("REQUIRE_APP_HEADERS_FOR_AUTH")),
+		MongoURI:               uri,
+		Database:               db,
+		JWTSecret:              jwtSecret,
+		AccessTokenMinutes:     accessTokenMinutes,
+		RefreshTokenDays:       refreshTokenDays,
+		JWTKeyRotationDays:     jwtKeyRotationDays,
+		TOTPIssuer:             totpIssuer,
+		GoogleClientID:         google,
+		GoogleClientIDiOS:      googleIOS,
+		GoogleClientIDAndroid:  googleAndroid,
+		GoogleClientIDs:        googleClientIDs,
+		AppleClientIDs:         buildClientIDList(os.Getenv("APPLE_CLIENT_IDS")),
+		OIDCProviderName:       oidcProviderName,
+		OIDCIssuer:             strings.TrimRight(strings.TrimSpace(os.Getenv("OIDC_ISSUER")), "/"),
+		OIDCClientIDs:          buildClientIDList(os.Getenv("OIDC_CLIENT_IDS")),
+		OIDCJWKSURL:            strings.TrimSpace(os.Getenv("OIDC_JWKS_URL")),
+		OIDCLinkByEmail:        isTruthy(os.Getenv("OIDC_LINK_BY_EMAIL")),
+		DefaultSearchesLeft:    defaultSearches,
+		WordTrashRetentionDays: wordTrashRetentionDays,
+		AccountDeletionDays:    accountDeletionDays,
+		MinAndroidVersionCode:  minAndroidVersionCode,
+		MaxAndroidVersionCode:  maxAndroidVersionCode,
+		AndroidUpdateURL:       strings.TrimSpace(os.Getenv("ANDROID_UPDATE_URL")),
+		RequireAppHeadersAuth:  isTruthy(os.Getenv("REQUIRE_APP_HEADERS_FOR_AUTH")),
+		TrustedProxies:         parseTrustedProxies(os.Getenv("TRUSTED_PROXIES")),
+	}
+}
+
+// parseTrustedProxies reads a comma-separated list of proxy IPs and CIDR ranges.
+func parseTrustedProxies(value string) []*net.IPNet {
+	var proxies []*net.IPNet
+	for _, entry := range buildClientIDList(value) {
+		if ip := net.ParseIP(entry); ip != nil {
+			if ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			log.Fatal("Invalid TRUSTED_PROXIES entry: ", entry)
+		}
+		proxies = append(proxies, ipNet)
 	}
+	return proxies
 }
 
-func buildGoogleClientIDList(values ...string) []string {
+func buildClientIDList(values ...string) []string {
 	seen := make(map[string]struct{})
 	var ids []string
 
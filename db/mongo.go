@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"USDT_BackEnd/config"
+	"USDT_BackEnd/utils"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -39,10 +40,21 @@ func ConnectDB(cfg *config.Config) {
 	// Auto setup
 	ensureCollectionsAndIndexes(ctx)
 	seedInitialData(ctx)
+	migrateUsers(ctx)
+	migrateWords(ctx)
+	migrateDictionaries(ctx)
+
+	// Walks every word, so it runs in the background with its own budget
+	// instead of holding up startup on the connection timeout.
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+		defer cancel()
+		migrateWordReadings(ctx)
+	}()
 }
 
 func ensureCollectionsAndIndexes(ctx context.Context) {
-	collections := []string{"users", "words", "subscriptions", "password_otps"}
+	collections := []string{"users", "words", "subscriptions", "password_otps", "sessions", "signing_keys", "email_verifications", "login_attempts", "app_settings", "api_keys", "audit_logs", "word_revisions", "data_exports", "tags", "word_relations", "kanji", "dictionaries"}
 
 	existing, _ := Database.ListCollectionNames(ctx, bson.D{})
 	existingMap := make(map[string]bool)
@@ -69,6 +81,25 @@ func ensureCollectionsAndIndexes(ctx context.Context) {
 	}
 	_, _ = Database.Collection("users").Indexes().CreateOne(ctx, userIdx)
 
+	// users: accounts waiting for the deletion purge
+	deleteAfterIdx := mongo.IndexModel{
+		Keys: bson.D{{Key: "deleteAfter", Value: 1}},
+		Options: options.Index().
+			SetPartialFilterExpression(bson.M{"deleteAfter": bson.M{"$exists": true}}).
+			SetName("delete_after"),
+	}
+	_, _ = Database.Collection("users").Indexes().CreateOne(ctx, deleteAfterIdx)
+
+	// users: one account per external provider identity
+	identityIdx := mongo.IndexModel{
+		Keys: bson.D{{Key: "identities.provider", Value: 1}, {Key: "identities.subject", Value: 1}},
+		Options: options.Index().
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"identities.subject": bson.M{"$exists": true}}).
+			SetName("unique_identity"),
+	}
+	_, _ = Database.Collection("users").Indexes().CreateOne(ctx, identityIdx)
+
 	// words: text index for search
 	wordIdx := mongo.IndexModel{
 		Keys: bson.D{
@@ -88,6 +119,183 @@ func ensureCollectionsAndIndexes(ctx context.Context) {
 	}
 	_, _ = Database.Collection("subscriptions").Indexes().CreateOne(ctx, subIdx)
 
+	// sessions: refresh token lookup, per-user listing, and automatic cleanup after expiry
+	sessionIdx := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "tokenHash", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("unique_token_hash"),
+		},
+		{
+			Keys:    bson.D{{Key: "familyId", Value: 1}},
+			Options: options.Index().SetName("family_id"),
+		},
+		{
+			Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "createdAt", Value: -1}},
+			Options: options.Index().SetName("user_sessions"),
+		},
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0).SetName("ttl_expires_at"),
+		},
+	}
+	_, _ = Database.Collection("sessions").Indexes().CreateMany(ctx, sessionIdx)
+
+	// signing_keys: lookup by kid, removed once retired
+	signingKeyIdx := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "kid", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("unique_kid"),
+		},
+		{
+			Keys:    bson.D{{Key: "retiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0).SetName("ttl_retires_at"),
+		},
+		{
+			// One successor per key; keys created before rotatesFrom existed are left out
+			Keys: bson.D{{Key: "rotatesFrom", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("unique_rotates_from").
+				SetPartialFilterExpression(bson.M{"rotatesFrom": bson.M{"$exists": true}}),
+		},
+	}
+	_, _ = Database.Collection("signing_keys").Indexes().CreateMany(ctx, signingKeyIdx)
+
+	// password_otps / email_verifications: one pending code per email, removed when expired
+	otpIdx := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "email", Value: 1}},
+			Options: options.Index().SetName("email"),
+		},
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0).SetName("ttl_expires_at"),
+		},
+	}
+	_, _ = Database.Collection("password_otps").Indexes().CreateMany(ctx, otpIdx)
+	_, _ = Database.Collection("email_verifications").Indexes().CreateMany(ctx, otpIdx)
+
+	// api_keys: lookup by hash on every request
+	apiKeyIdx := mongo.IndexModel{
+		Keys:    bson.D{{Key: "keyHash", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("unique_key_hash"),
+	}
+	_, _ = Database.Collection("api_keys").Indexes().CreateOne(ctx, apiKeyIdx)
+
+	// audit_logs: newest first, filtered by actor, action or target
+	auditIdx := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "createdAt", Value: -1}},
+			Options: options.Index().SetName("created_at"),
+		},
+		{
+			Keys:    bson.D{{Key: "actor.userId", Value: 1}, {Key: "createdAt", Value: -1}},
+			Options: options.Index().SetName("actor_created_at"),
+		},
+		{
+			Keys:    bson.D{{Key: "action", Value: 1}, {Key: "createdAt", Value: -1}},
+			Options: options.Index().SetName("action_created_at"),
+		},
+		{
+			Keys:    bson.D{{Key: "targetId", Value: 1}, {Key: "createdAt", Value: -1}},
+			Options: options.Index().SetName("target_created_at"),
+		},
+	}
+	_, _ = Database.Collection("audit_logs").Indexes().CreateMany(ctx, auditIdx)
+
+	// words: trash listing and purge
+	trashIdx := mongo.IndexModel{
+		Keys: bson.D{{Key: "deletedAt", Value: 1}},
+		Options: options.Index().
+			SetPartialFilterExpression(bson.M{"deletedAt": bson.M{"$exists": true}}).
+			SetName("deleted_at"),
+	}
+	_, _ = Database.Collection("words").Indexes().CreateOne(ctx, trashIdx)
+
+	// word_revisions: one numbered revision per change to a word
+	revisionIdx := mongo.IndexModel{
+		Keys:    bson.D{{Key: "wordId", Value: 1}, {Key: "revision", Value: -1}},
+		Options: options.Index().SetUnique(true).SetName("unique_word_revision"),
+	}
+	_, _ = Database.Collection("word_revisions").Indexes().CreateOne(ctx, revisionIdx)
+
+	// words: dictionary, tag and JLPT level filters, newest first like the admin listing
+	categoryIdx := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "tags", Value: 1}, {Key: "createdAt", Value: -1}},
+			Options: options.Index().SetName("tags_created_at"),
+		},
+		{
+			Keys:    bson.D{{Key: "jlptLevel", Value: 1}, {Key: "createdAt", Value: -1}},
+			Options: options.Index().SetName("jlpt_level_created_at"),
+		},
+		{
+			Keys:    bson.D{{Key: "dictionaryId", Value: 1}, {Key: "createdAt", Value: -1}},
+			Options: options.Index().SetName("dictionary_created_at"),
+		},
+	}
+	_, _ = Database.Collection("words").Indexes().CreateMany(ctx, categoryIdx)
+
+	// words: exact match on alternative readings in the admin text search
+	readingIdx := mongo.IndexModel{
+		Keys:    bson.D{{Key: "readings.kana", Value: 1}},
+		Options: options.Index().SetName("readings_kana"),
+	}
+	_, _ = Database.Collection("words").Indexes().CreateOne(ctx, readingIdx)
+
+	// word_relations: one relation per pair of words, looked up from either side
+	relationIdx := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "from", Value: 1}, {Key: "to", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("unique_pair"),
+		},
+		{
+			Keys:    bson.D{{Key: "to", Value: 1}},
+			Options: options.Index().SetName("to"),
+		},
+	}
+	_, _ = Database.Collection("word_relations").Indexes().CreateMany(ctx, relationIdx)
+
+	// tags: names are unique, and words refer to tags by name
+	tagIdx := mongo.IndexModel{
+		Keys:    bson.D{{Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("unique_name"),
+	}
+	_, _ = Database.Collection("tags").Indexes().CreateOne(ctx, tagIdx)
+
+	// dictionaries: unique names, and a single default
+	dictionaryIdx := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "name", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("unique_name"),
+		},
+		{
+			Keys: bson.D{{Key: "isDefault", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("single_default").
+				SetPartialFilterExpression(bson.M{"isDefault": true}),
+		},
+	}
+	_, _ = Database.Collection("dictionaries").Indexes().CreateMany(ctx, dictionaryIdx)
+
+	// kanji: one entry per character; imports upsert on it
+	kanjiIdx := mongo.IndexModel{
+		Keys:    bson.D{{Key: "character", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("unique_character"),
+	}
+	_, _ = Database.Collection("kanji").Indexes().CreateOne(ctx, kanjiIdx)
+
+	// data_exports: latest export per user
+	exportIdx := mongo.IndexModel{
+		Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "createdAt", Value: -1}},
+		Options: options.Index().SetName("user_created_at"),
+	}
+	_, _ = Database.Collection("data_exports").Indexes().CreateOne(ctx, exportIdx)
+
+	// login_attempts: failure counters are forgotten after a quiet period
+	attemptIdx := mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0).SetName("ttl_expires_at"),
+	}
+	_, _ = Database.Collection("login_attempts").Indexes().CreateOne(ctx, attemptIdx)
+
 	log.Println("✅ Collections and indexes verified/created.")
 }
 func seedInitialData(ctx context.Context) {
@@ -141,3 +349,183 @@ func seedInitialData(ctx context.Context) {
 
 	log.Println("🌱 Subscription schema migrated & ensured.")
 }
+
+func migrateUsers(ctx context.Context) {
+	users := Database.Collection("users")
+
+	// Accounts created before email verification existed are treated as verified.
+	res, err := users.UpdateMany(
+		ctx,
+		bson.M{"emailVerified": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"emailVerified": true}},
+	)
+	if err != nil {
+		log.Println("❌ User emailVerified migration failed:", err)
+		return
+	}
+	if res.ModifiedCount > 0 {
+		log.Printf("🌱 Marked %d existing users as email verified.", res.ModifiedCount)
+	}
+
+	// The single googleId field became an entry in the identities array.
+	res, err = users.UpdateMany(
+		ctx,
+		bson.M{"googleId": bson.M{"$nin": bson.A{"", nil}}, "identities": bson.M{"$exists": false}},
+		mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{"identities": bson.A{bson.M{
+				"provider": "google",
+				"subject":  "$googleId",
+				"email":    "$email",
+				"linkedAt": "$createdAt",
+			}}}}},
+		},
+	)
+	if err != nil {
+		log.Println("❌ User identities migration failed:", err)
+		return
+	}
+	if res.ModifiedCount > 0 {
+		log.Printf("🌱 Moved %d Google IDs into identities.", res.ModifiedCount)
+	}
+	if _, err := users.UpdateMany(
+		ctx,
+		bson.M{"$or": bson.A{bson.M{"googleId": bson.M{"$exists": true}}, bson.M{"authProvider": bson.M{"$exists": true}}}},
+		bson.M{"$unset": bson.M{"googleId": "", "authProvider": ""}},
+	); err != nil {
+		log.Println("❌ Removing legacy auth provider fields failed:", err)
+	}
+}
+
+// migrateWords gives words saved before senses existed a single sense built from
+// their flat English and Myanmar fields.
+func migrateWords(ctx context.Context) {
+	res, err := Database.Collection("words").UpdateMany(
+		ctx,
+		bson.M{"senses": bson.M{"$exists": false}},
+		mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{"senses": bson.M{"$cond": bson.A{
+				bson.M{"$and": bson.A{
+					bson.M{"$eq": bson.A{bson.M{"$ifNull": bson.A{"$english", ""}}, ""}},
+					bson.M{"$eq": bson.A{bson.M{"$ifNull": bson.A{"$myanmar", ""}}, ""}},
+				}},
+				bson.A{},
+				bson.A{bson.M{
+					"english": bson.M{"$ifNull": bson.A{"$english", ""}},
+					"myanmar": bson.M{"$ifNull": bson.A{"$myanmar", ""}},
+				}},
+			}}}}},
+		},
+	)
+	if err != nil {
+		log.Println("❌ Word senses migration failed:", err)
+		return
+	}
+	if res.ModifiedCount > 0 {
+		log.Printf("🌱 Gave %d words a single sense from their flat glosses.", res.ModifiedCount)
+	}
+}
+
+// migrateDictionaries creates the default dictionary and files every word saved
+// before dictionaries existed in it.
+func migrateDictionaries(ctx context.Context) {
+	now := time.Now()
+	var general struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	err := Database.Collection("dictionaries").FindOneAndUpdate(
+		ctx,
+		bson.M{"isDefault": true},
+		bson.M{"$setOnInsert": bson.M{"name": "General", "createdAt": now, "updatedAt": now}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&general)
+	if err != nil {
+		log.Println("❌ Default dictionary setup failed:", err)
+		return
+	}
+
+	res, err := Database.Collection("words").UpdateMany(
+		ctx,
+		bson.M{"dictionaryId": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"dictionaryId": general.ID}},
+	)
+	if err != nil {
+		log.Println("❌ Word dictionary migration failed:", err)
+		return
+	}
+	if res.ModifiedCount > 0 {
+		log.Printf("🌱 Filed %d words in the default dictionary.", res.ModifiedCount)
+	}
+}
+
+// migrateWordReadings gives words saved before readings existed their SubTerm as
+// the only reading, and furigana segments over it. Each word is skipped once it has
+// furigana, so an interrupted run picks up where it stopped and a word edited
+// meanwhile keeps its new furigana.
+func migrateWordReadings(ctx context.Context) {
+	words := Database.Collection("words")
+	cursor, err := words.Find(ctx,
+		bson.M{"furigana": bson.M{"$exists": false}, "japanese": bson.M{"$nin": bson.A{"", nil}}},
+		options.Find().SetProjection(bson.M{"japanese": 1, "subTerm": 1, "readings": 1}),
+	)
+	if err != nil {
+		log.Println("❌ Word readings migration failed:", err)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	const batchSize = 1000
+	var batch []mongo.WriteModel
+	migrated := 0
+	flush := func() bool {
+		if len(batch) == 0 {
+			return true
+		}
+		res, err := words.BulkWrite(ctx, batch, options.BulkWrite().SetOrdered(false))
+		if err != nil {
+			log.Println("❌ Word readings migration failed:", err)
+			return false
+		}
+		migrated += int(res.ModifiedCount)
+		batch = batch[:0]
+		return true
+	}
+
+	for cursor.Next(ctx) {
+		var word struct {
+			ID       primitive.ObjectID `bson:"_id"`
+			Japanese string             `bson:"japanese"`
+			SubTerm  string             `bson:"subTerm"`
+			Readings bson.A             `bson:"readings"`
+		}
+		if err := cursor.Decode(&word); err != nil {
+			log.Println("❌ Word readings migration failed:", err)
+			return
+		}
+
+		segments, _ := utils.SegmentFurigana(word.Japanese, word.SubTerm)
+		furigana := make(bson.A, len(segments))
+		for i, seg := range segments {
+			doc := bson.M{"text": seg.Text}
+			if seg.Reading != "" {
+				doc["reading"] = seg.Reading
+			}
+			furigana[i] = doc
+		}
+		set := bson.M{"furigana": furigana}
+		if len(word.Readings) == 0 && word.SubTerm != "" {
+			set["readings"] = bson.A{bson.M{"kana": word.SubTerm}}
+		}
+		batch = append(batch, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": word.ID, "furigana": bson.M{"$exists": false}}).
+			SetUpdate(bson.M{"$set": set}))
+		if len(batch) == batchSize && !flush() {
+			return
+		}
+	}
+	if !flush() {
+		return
+	}
+	if migrated > 0 {
+		log.Printf("🌱 Added readings and furigana to %d words.", migrated)
+	}
+}
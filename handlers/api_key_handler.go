@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"USDT_BackEnd/models"
+	"USDT_BackEnd/services"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type APIKeyHandler struct {
+	service *services.APIKeyService
+	audit   *services.AuditService
+}
+
+func NewAPIKeyHandler(service *services.APIKeyService, audit *services.AuditService) *APIKeyHandler {
+	return &APIKeyHandler{service: service, audit: audit}
+}
+
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expiresInDays"` // 0 = never expires
+}
+
+// POST /api/admin/api-keys
+func (h *APIKeyHandler) CreateKey(w http.ResponseWriter, r *http.Request, userID primitive.ObjectID) {
+	log.Println("[DEBUG] CreateAPIKey endpoint called by userID:", userID.Hex())
+	var req CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	key, plaintext, err := h.service.CreateKey(r.Context(), userID, req.Name, req.Scopes, req.ExpiresInDays)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidScope) {
+			writeError(w, http.StatusBadRequest, "INVALID_SCOPE", err.Error())
+			return
+		}
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	event := auditEvent(r, models.AuditAPIKeyCreate, "api_key", key.ID.Hex())
+	// Never the hash: the log only needs to identify the key.
+	event.After = map[string]interface{}{
+		"name":      key.Name,
+		"prefix":    key.Prefix,
+		"scopes":    key.Scopes,
+		"expiresAt": key.ExpiresAt,
+	}
+	h.audit.Record(r.Context(), event)
+
+	// The plaintext key is only ever returned here.
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"key":    plaintext,
+		"apiKey": key,
+	})
+}
+
+// GET /api/admin/api-keys
+func (h *APIKeyHandler) ListKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.service.ListKeys(r.Context())
+	if err != nil {
+		log.Println("[ERROR] ListAPIKeys failed:", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(keys)
+}
+
+// DELETE /api/admin/api-keys/{id}
+func (h *APIKeyHandler) RevokeKey(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	log.Println("[DEBUG] RevokeAPIKey endpoint called for:", id)
+
+	if err := h.service.RevokeKey(r.Context(), id); err != nil {
+		if errors.Is(err, services.ErrAPIKeyNotFound) {
+			http.Error(w, "API key not found", http.StatusNotFound)
+			return
+		}
+		log.Println("[ERROR] RevokeAPIKey failed:", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.audit.Record(r.Context(), auditEvent(r, models.AuditAPIKeyRevoke, "api_key", id))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "API key revoked"})
+}
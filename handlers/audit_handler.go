@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"USDT_BackEnd/repository"
+	"USDT_BackEnd/services"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AuditHandler struct {
+	service *services.AuditService
+}
+
+func NewAuditHandler(service *services.AuditService) *AuditHandler {
+	return &AuditHandler{service: service}
+}
+
+// ------------------- Admin: Query Audit Log -------------------
+// GET /api/admin/audit?actor=&action=&targetType=&targetId=&from=&to=&page=&limit=
+// actor is a user ID or an email; from/to are RFC 3339 times or YYYY-MM-DD dates (to is inclusive).
+func (h *AuditHandler) GetAuditLogs(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	page, _ := strconv.Atoi(q.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	if limit < 1 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	filter := repository.AuditFilter{
+		Action:     q.Get("action"),
+		TargetType: q.Get("targetType"),
+		TargetID:   q.Get("targetId"),
+	}
+	if actor := strings.TrimSpace(q.Get("actor")); actor != "" {
+		if id, err := primitive.ObjectIDFromHex(actor); err == nil {
+			filter.ActorID = id
+		} else {
+			filter.ActorEmail = actor
+		}
+	}
+
+	var err error
+	if filter.From, err = parseAuditTime(q.Get("from"), false); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "from must be an RFC 3339 time or a YYYY-MM-DD date")
+		return
+	}
+	if filter.To, err = parseAuditTime(q.Get("to"), true); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "to must be an RFC 3339 time or a YYYY-MM-DD date")
+		return
+	}
+
+	logs, hasMore, totalCount, err := h.service.Query(r.Context(), filter, page, limit)
+	if err != nil {
+		log.Println("[ERROR] GetAuditLogs failed:", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"logs":        logs,
+		"hasMore":     hasMore,
+		"currentPage": page,
+		"totalCount":  totalCount,
+	})
+}
+
+// parseAuditTime accepts an RFC 3339 time or a date. A date used as an upper
+// bound means the end of that day.
+func parseAuditTime(s string, endOfDay bool) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"USDT_BackEnd/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ------------------- Export My Data -------------------
+// Answers 202 while the archive is being built; poll again until it returns 200
+// with a downloadUrl. The link expires after linkExpiresIn seconds.
+func (h *UserHandler) ExportMyData(w http.ResponseWriter, r *http.Request, userID primitive.ObjectID) {
+	log.Println("[DEBUG] ExportMyData endpoint called for userID:", userID.Hex())
+
+	status, err := h.service.RequestDataExport(r.Context(), userID)
+	if err != nil {
+		log.Println("[ERROR] ExportMyData failed for userID:", userID.Hex(), "error:", err)
+		http.Error(w, "Failed to start data export", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if status.Status != models.ExportReady {
+		w.Header().Set("Retry-After", "10")
+		w.WriteHeader(http.StatusAccepted)
+	}
+	json.NewEncoder(w).Encode(status)
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"USDT_BackEnd/models"
+	"USDT_BackEnd/services"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type DictionaryHandler struct {
+	service *services.DictionaryService
+	audit   *services.AuditService
+}
+
+func NewDictionaryHandler(service *services.DictionaryService, audit *services.AuditService) *DictionaryHandler {
+	return &DictionaryHandler{service: service, audit: audit}
+}
+
+type DictionaryRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// GET /api/dictionaries (also /api/admin/dictionaries)
+func (h *DictionaryHandler) ListDictionaries(w http.ResponseWriter, r *http.Request) {
+	dictionaries, err := h.service.ListDictionaries(r.Context())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get dictionaries: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dictionaries)
+}
+
+// POST /api/admin/dictionaries
+func (h *DictionaryHandler) CreateDictionary(w http.ResponseWriter, r *http.Request) {
+	var req DictionaryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	dictionary, err := h.service.CreateDictionary(r.Context(), req.Name, req.Description)
+	if err != nil {
+		writeDictionaryError(w, err)
+		return
+	}
+
+	event := auditEvent(r, models.AuditDictionaryCreate, "dictionary", dictionary.ID.Hex())
+	event.After = dictionary
+	h.audit.Record(r.Context(), event)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(dictionary)
+}
+
+// PUT /api/admin/dictionaries/{id}
+func (h *DictionaryHandler) UpdateDictionary(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	var req DictionaryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	before, after, err := h.service.UpdateDictionary(r.Context(), id, req.Name, req.Description)
+	if err != nil {
+		writeDictionaryError(w, err)
+		return
+	}
+
+	event := auditEvent(r, models.AuditDictionaryUpdate, "dictionary", id)
+	event.Before, event.After = before, after
+	h.audit.Record(r.Context(), event)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(after)
+}
+
+// DELETE /api/admin/dictionaries/{id}
+func (h *DictionaryHandler) DeleteDictionary(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	dictionary, err := h.service.DeleteDictionary(r.Context(), id)
+	if err != nil {
+		writeDictionaryError(w, err)
+		return
+	}
+
+	event := auditEvent(r, models.AuditDictionaryDelete, "dictionary", id)
+	event.Before = dictionary
+	h.audit.Record(r.Context(), event)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /api/users/me/dictionaries
+func (h *DictionaryHandler) GetSearchDictionaries(w http.ResponseWriter, r *http.Request, userID primitive.ObjectID) {
+	ids, err := h.service.GetSearchDictionaries(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"dictionaries": ids,
+	})
+}
+
+// PUT /api/users/me/dictionaries {"dictionaries": ["..."]}; an empty list searches every dictionary
+func (h *DictionaryHandler) SetSearchDictionaries(w http.ResponseWriter, r *http.Request, userID primitive.ObjectID) {
+	var req struct {
+		Dictionaries []string `json:"dictionaries"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	ids, err := h.service.SetSearchDictionaries(r.Context(), userID, req.Dictionaries)
+	if err != nil {
+		writeDictionaryError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"dictionaries": ids,
+	})
+}
+
+func writeDictionaryError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrDictionaryNotFound):
+		http.Error(w, "Dictionary not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrDictionaryExists), errors.Is(err, services.ErrDictionaryNotEmpty),
+		errors.Is(err, services.ErrDefaultDictionary):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, services.ErrInvalidDictionaryName), errors.Is(err, services.ErrUnknownDictionary):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, fmt.Sprintf("Failed to save dictionary: %v", err), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+type VerifyEmailRequest struct {
+	Email string `json:"email"`
+	OTP   string `json:"otp"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email"`
+}
+
+func (h *UserHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	log.Println("[DEBUG] VerifyEmail endpoint called")
+	var req VerifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if req.Email == "" || req.OTP == "" {
+		http.Error(w, "Email and OTP are required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.VerifyEmail(r.Context(), req.Email, req.OTP, clientInfo(r)); err != nil {
+		log.Println("[ERROR] VerifyEmail failed for:", req.Email, "error:", err)
+		writeOTPError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Email verified",
+	})
+}
+
+func (h *UserHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	log.Println("[DEBUG] ResendVerification endpoint called")
+	var req ResendVerificationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if req.Email == "" {
+		http.Error(w, "Email is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.SendVerificationOTP(r.Context(), req.Email, clientInfo(r)); err != nil {
+		log.Println("[ERROR] ResendVerification failed for:", req.Email, "error:", err)
+		if writeThrottled(w, err) {
+			return
+		}
+		http.Error(w, "Could not send verification code", http.StatusInternalServerError)
+		return
+	}
+
+	// Same answer whether or not the email needs verifying.
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "If the account needs verification, a code has been sent",
+	})
+}
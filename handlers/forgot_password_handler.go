@@ -32,17 +32,16 @@ func (h *UserHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	log.Println("[DEBUG] ForgotPassword: sending OTP to:", req.Email)
-	if err := h.service.SendResetOTP(r.Context(), req.Email); err != nil {
-		log.Println("[ERROR] ForgotPassword: failed to send OTP:", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	log.Println("[DEBUG] ForgotPassword: requesting OTP for:", req.Email)
+	if err := h.service.SendResetOTP(r.Context(), req.Email, clientInfo(r)); writeThrottled(w, err) {
+		log.Println("[DEBUG] ForgotPassword: throttled for:", req.Email)
 		return
 	}
 
-	log.Println("[DEBUG] ForgotPassword: OTP sent successfully to:", req.Email)
+	// Same response whether or not the email is registered.
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{
-		"message": "OTP sent to email",
+		"message": "If an account exists for this email, an OTP has been sent",
 	})
 }
 
@@ -64,10 +63,13 @@ func (h *UserHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
 
 	log.Println("[DEBUG] ResetPassword: attempting reset for:", req.Email)
 	if err := h.service.ResetPasswordWithOTP(
-		r.Context(), req.Email, req.OTP, req.NewPassword,
+		r.Context(), req.Email, req.OTP, req.NewPassword, clientInfo(r),
 	); err != nil {
 		log.Println("[ERROR] ResetPassword: failed for:", req.Email, "error:", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		if writePasswordPolicyError(w, err) {
+			return
+		}
+		writeOTPError(w, err)
 		return
 	}
 
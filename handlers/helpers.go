@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"USDT_BackEnd/middleware"
+	"USDT_BackEnd/models"
+	"USDT_BackEnd/services"
+)
+
+// clientInfo extracts the caller's IP and user agent for session bookkeeping.
+func clientInfo(r *http.Request) services.ClientInfo {
+	return services.ClientInfo{IP: middleware.ClientIP(r), UserAgent: r.UserAgent()}
+}
+
+// currentActor identifies the admin, or API key, making the request.
+func currentActor(r *http.Request) models.AuditActor {
+	var actor models.AuditActor
+	if user := middleware.CurrentUser(r); user != nil {
+		actor.UserID, actor.Email = user.ID, user.Email
+	}
+	if apiKey := middleware.APIKey(r); apiKey != nil {
+		actor.APIKeyID = &apiKey.ID
+		actor.APIKeyName = apiKey.Name
+	}
+	return actor
+}
+
+// auditEvent starts an audit entry for the admin, or API key, making the request.
+func auditEvent(r *http.Request, action, targetType, targetID string) services.AuditEvent {
+	client := clientInfo(r)
+	return services.AuditEvent{
+		Actor:      currentActor(r),
+		IP:         client.IP,
+		UserAgent:  client.UserAgent,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{
+		"code":    code,
+		"message": message,
+	})
+}
+
+// writeAuthTokens answers a successful sign-in. When the user has 2FA enabled it
+// only returns the challenge token for POST /api/auth/2fa/verify; otherwise it
+// returns the token pair together with extra.
+func writeAuthTokens(w http.ResponseWriter, tokens *services.AuthTokens, extra map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if tokens.ChallengeToken != "" {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"twoFactorRequired": true,
+			"challengeToken":    tokens.ChallengeToken,
+			"expiresIn":         tokens.ExpiresIn,
+		})
+		return
+	}
+
+	resp := map[string]interface{}{
+		"token":        tokens.AccessToken,
+		"refreshToken": tokens.RefreshToken,
+		"expiresIn":    tokens.ExpiresIn,
+	}
+	for k, v := range extra {
+		resp[k] = v
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// writePasswordPolicyError answers 400 with every policy violation when err is a
+// *services.PasswordPolicyError. It returns false (and writes nothing) for any other error.
+func writePasswordPolicyError(w http.ResponseWriter, err error) bool {
+	var policyErr *services.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code":       "WEAK_PASSWORD",
+		"message":    policyErr.Error(),
+		"violations": policyErr.Violations,
+	})
+	return true
+}
+
+// writeThrottled answers 429 with a Retry-After header when err is a lockout.
+// It returns false (and writes nothing) for any other error.
+func writeThrottled(w http.ResponseWriter, err error) bool {
+	var throttled *services.ThrottleError
+	if !errors.As(err, &throttled) {
+		return false
+	}
+	seconds := throttled.RetryAfterSeconds()
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	w.WriteHeader(http.StatusTooManyRequests)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code":       "TOO_MANY_ATTEMPTS",
+		"message":    throttled.Error(),
+		"retryAfter": seconds,
+	})
+	return true
+}
+
+// writeOTPError maps a one-time code failure to its JSON error code.
+func writeOTPError(w http.ResponseWriter, err error) {
+	if writeThrottled(w, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, services.ErrOTPAttemptsExceeded):
+		writeError(w, http.StatusBadRequest, "OTP_ATTEMPTS_EXCEEDED", err.Error())
+	case errors.Is(err, services.ErrInvalidOTP):
+		writeError(w, http.StatusBadRequest, "INVALID_OTP", err.Error())
+	default:
+		writeError(w, http.StatusBadRequest, "REQUEST_FAILED", err.Error())
+	}
+}
@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"USDT_BackEnd/services"
+)
+
+type JWKSHandler struct {
+	keys *services.KeyManager
+}
+
+func NewJWKSHandler(keys *services.KeyManager) *JWKSHandler {
+	return &JWKSHandler{keys: keys}
+}
+
+// GET /.well-known/jwks.json
+func (h *JWKSHandler) GetJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"keys": h.keys.JWKS(),
+	})
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"USDT_BackEnd/models"
+	"USDT_BackEnd/services"
+)
+
+type KanjiHandler struct {
+	service *services.KanjiService
+	audit   *services.AuditService
+}
+
+func NewKanjiHandler(service *services.KanjiService, audit *services.AuditService) *KanjiHandler {
+	return &KanjiHandler{service: service, audit: audit}
+}
+
+// GET /api/kanji/{char}
+func (h *KanjiHandler) GetKanji(w http.ResponseWriter, r *http.Request) {
+	kanji, err := h.service.GetKanji(r.Context(), r.PathValue("char"))
+	if err != nil {
+		writeKanjiError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(kanji)
+}
+
+// POST /api/admin/kanji/import (multipart "file": kanjidic2.xml or kanjidic2.xml.gz)
+func (h *KanjiHandler) ImportKanji(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, services.MaxKanjidicUploadBytes+1<<20) // room for the multipart framing
+	if err := r.ParseMultipartForm(services.MaxKanjidicUploadBytes + 1<<20); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, fmt.Sprintf("file must be at most %d MB", services.MaxKanjidicUploadBytes>>20), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "Failed to read uploaded file", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	// The full dictionary has about 13,000 entries
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Minute)
+	defer cancel()
+
+	result, err := h.service.ImportKanjidic(ctx, file)
+
+	// Recorded even when the file broke off partway, since earlier batches were saved.
+	if err == nil || result.Inserted+result.Updated > 0 {
+		event := auditEvent(r, models.AuditKanjiImport, "kanji", "")
+		event.Metadata = map[string]interface{}{
+			"file":     header.Filename,
+			"parsed":   result.Parsed,
+			"inserted": result.Inserted,
+			"updated":  result.Updated,
+		}
+		h.audit.Record(r.Context(), event)
+	}
+
+	if errors.Is(err, services.ErrMalformedKanjidic) || errors.Is(err, services.ErrKanjidicTooLarge) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":  err.Error(),
+			"parsed":   result.Parsed,
+			"inserted": result.Inserted,
+			"updated":  result.Updated,
+		})
+		return
+	}
+	if err != nil {
+		writeKanjiError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// PUT /api/admin/kanji/{char} {"myanmarMeanings": ["..."]}
+func (h *KanjiHandler) UpdateKanji(w http.ResponseWriter, r *http.Request) {
+	character := r.PathValue("char")
+	var req struct {
+		MyanmarMeanings []string `json:"myanmarMeanings"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	before, after, err := h.service.UpdateMyanmarMeanings(r.Context(), character, req.MyanmarMeanings)
+	if err != nil {
+		writeKanjiError(w, err)
+		return
+	}
+
+	event := auditEvent(r, models.AuditKanjiUpdate, "kanji", character)
+	event.Before, event.After = before, after
+	h.audit.Record(r.Context(), event)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(after)
+}
+
+func writeKanjiError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrKanjiNotFound):
+		http.Error(w, "Kanji not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrNotKanji), errors.Is(err, services.ErrInvalidKanjidic):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, fmt.Sprintf("Failed to process kanji: %v", err), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"USDT_BackEnd/services"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ProviderAuthRequest carries the ID token from Google, Apple or the configured OIDC provider.
+type ProviderAuthRequest struct {
+	IdToken string `json:"idToken"`
+}
+
+// ------------------- External Provider Login -------------------
+func (h *UserHandler) ProviderLogin(w http.ResponseWriter, r *http.Request) {
+	provider := r.PathValue("provider")
+	log.Println("[DEBUG] ProviderLogin endpoint called for:", provider)
+	var req ProviderAuthRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.IdToken == "" {
+		log.Println("[ERROR] ProviderLogin: invalid request body")
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	log.Println("[DEBUG] ProviderLogin: token length:", len(req.IdToken))
+
+	tokens, user, err := h.service.ProviderLogin(r.Context(), provider, req.IdToken, clientInfo(r))
+	if err != nil {
+		log.Println("[ERROR] ProviderLogin failed:", err)
+		writeProviderError(w, err, http.StatusUnauthorized)
+		return
+	}
+	if user == nil {
+		log.Println("[ERROR] ProviderLogin: user is nil")
+		http.Error(w, "Login failed", http.StatusInternalServerError)
+		return
+	}
+
+	log.Println("[DEBUG] ProviderLogin successful for:", user.Email)
+	writeAuthTokens(w, tokens, map[string]interface{}{
+		"user": user,
+	})
+}
+
+// ------------------- External Provider Register -------------------
+func (h *UserHandler) ProviderRegister(w http.ResponseWriter, r *http.Request) {
+	provider := r.PathValue("provider")
+	log.Println("[DEBUG] ProviderRegister endpoint called for:", provider)
+	var req ProviderAuthRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.IdToken == "" {
+		log.Println("[ERROR] ProviderRegister: invalid request body")
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	log.Println("[DEBUG] ProviderRegister: token length:", len(req.IdToken))
+
+	tokens, user, err := h.service.ProviderRegister(r.Context(), provider, req.IdToken, clientInfo(r))
+	if err != nil {
+		log.Println("[ERROR] ProviderRegister failed:", err)
+		writeProviderError(w, err, http.StatusBadRequest)
+		return
+	}
+	if user == nil {
+		log.Println("[ERROR] ProviderRegister: user is nil")
+		http.Error(w, "Registration failed", http.StatusInternalServerError)
+		return
+	}
+
+	log.Println("[DEBUG] ProviderRegister successful for:", user.Email)
+	writeAuthTokens(w, tokens, map[string]interface{}{
+		"user": user,
+	})
+}
+
+// writeProviderError keeps the plain-text errors the Google endpoints always returned,
+// with 404 for providers that are not enabled.
+func writeProviderError(w http.ResponseWriter, err error, status int) {
+	switch {
+	case errors.Is(err, services.ErrUnknownProvider):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, services.ErrInvalidIdentityToken):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	default:
+		http.Error(w, err.Error(), status)
+	}
+}
+
+type AddPasswordRequest struct {
+	NewPassword string `json:"newPassword"`
+}
+
+// LinkGoogle is the original Google-only link endpoint, kept for older app versions.
+func (h *UserHandler) LinkGoogle(w http.ResponseWriter, r *http.Request, userID primitive.ObjectID) {
+	h.linkIdentity(w, r, userID, "google")
+}
+
+// ------------------- Link Provider -------------------
+func (h *UserHandler) LinkIdentity(w http.ResponseWriter, r *http.Request, userID primitive.ObjectID) {
+	h.linkIdentity(w, r, userID, r.PathValue("provider"))
+}
+
+func (h *UserHandler) linkIdentity(w http.ResponseWriter, r *http.Request, userID primitive.ObjectID, provider string) {
+	log.Println("[DEBUG] LinkIdentity endpoint called for userID:", userID.Hex(), "provider:", provider)
+	var req ProviderAuthRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.IdToken == "" {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	updatedUser, err := h.service.LinkIdentity(r.Context(), userID, provider, req.IdToken)
+	if err != nil {
+		log.Println("[ERROR] LinkIdentity failed:", err)
+		writeIdentityError(w, err)
+		return
+	}
+
+	log.Println("[DEBUG] LinkIdentity successful for:", updatedUser.Email)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Account linked with " + provider + " successfully",
+		"user":    updatedUser,
+	})
+}
+
+// ------------------- Unlink Provider -------------------
+func (h *UserHandler) UnlinkIdentity(w http.ResponseWriter, r *http.Request, userID primitive.ObjectID) {
+	provider := r.PathValue("provider")
+	log.Println("[DEBUG] UnlinkIdentity endpoint called for userID:", userID.Hex(), "provider:", provider)
+
+	updatedUser, err := h.service.UnlinkIdentity(r.Context(), userID, provider)
+	if err != nil {
+		log.Println("[ERROR] UnlinkIdentity failed:", err)
+		writeIdentityError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Account unlinked from " + provider,
+		"user":    updatedUser,
+	})
+}
+
+// ------------------- Add Password -------------------
+func (h *UserHandler) AddPassword(w http.ResponseWriter, r *http.Request, userID primitive.ObjectID) {
+	log.Println("[DEBUG] AddPassword endpoint called for userID:", userID.Hex())
+	var req AddPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.NewPassword == "" {
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "newPassword is required")
+		return
+	}
+
+	if err := h.service.AddPassword(r.Context(), userID, req.NewPassword); err != nil {
+		log.Println("[ERROR] AddPassword failed:", err)
+		if writePasswordPolicyError(w, err) {
+			return
+		}
+		writeIdentityError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"message": "Password added"})
+}
+
+// writeIdentityError maps linking errors to JSON error codes.
+func writeIdentityError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrUnknownProvider):
+		writeError(w, http.StatusNotFound, "UNKNOWN_PROVIDER", err.Error())
+	case errors.Is(err, services.ErrInvalidIdentityToken):
+		writeError(w, http.StatusUnauthorized, "INVALID_IDENTITY_TOKEN", err.Error())
+	case errors.Is(err, services.ErrProviderAlreadyLinked):
+		writeError(w, http.StatusConflict, "PROVIDER_ALREADY_LINKED", err.Error())
+	case errors.Is(err, services.ErrIdentityAlreadyLinked):
+		writeError(w, http.StatusConflict, "IDENTITY_ALREADY_LINKED", err.Error())
+	case errors.Is(err, services.ErrProviderNotLinked):
+		writeError(w, http.StatusNotFound, "PROVIDER_NOT_LINKED", err.Error())
+	case errors.Is(err, services.ErrLastSignInMethod):
+		writeError(w, http.StatusConflict, "LAST_SIGN_IN_METHOD", err.Error())
+	case errors.Is(err, services.ErrPasswordAlreadySet):
+		writeError(w, http.StatusConflict, "PASSWORD_ALREADY_SET", err.Error())
+	default:
+		writeError(w, http.StatusBadRequest, "REQUEST_FAILED", err.Error())
+	}
+}
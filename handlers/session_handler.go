@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"USDT_BackEnd/middleware"
+	"USDT_BackEnd/models"
+	"USDT_BackEnd/services"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// ------------------- Refresh Access Token -------------------
+func (h *UserHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	log.Println("[DEBUG] RefreshToken endpoint called")
+	var req RefreshTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "refreshToken is required")
+		return
+	}
+
+	tokens, err := h.service.RefreshSession(r.Context(), req.RefreshToken, clientInfo(r))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrRefreshTokenReused):
+			writeError(w, http.StatusUnauthorized, err.Error(), "This session was revoked, please sign in again")
+		case errors.Is(err, services.ErrInvalidRefreshToken):
+			writeError(w, http.StatusUnauthorized, err.Error(), "Invalid or expired refresh token")
+		default:
+			log.Println("[ERROR] RefreshToken failed:", err)
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Could not refresh session")
+		}
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"token":        tokens.AccessToken,
+		"refreshToken": tokens.RefreshToken,
+		"expiresIn":    tokens.ExpiresIn,
+	})
+}
+
+// ------------------- Logout -------------------
+func (h *UserHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	log.Println("[DEBUG] Logout endpoint called")
+	var req RefreshTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "refreshToken is required")
+		return
+	}
+
+	if err := h.service.Logout(r.Context(), req.RefreshToken); err != nil && !errors.Is(err, services.ErrInvalidRefreshToken) {
+		log.Println("[ERROR] Logout failed:", err)
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Could not log out")
+		return
+	}
+
+	// Unknown tokens are treated as already logged out.
+	json.NewEncoder(w).Encode(map[string]string{"message": "Logged out"})
+}
+
+// ------------------- List Sessions -------------------
+func (h *UserHandler) ListSessions(w http.ResponseWriter, r *http.Request, userID primitive.ObjectID) {
+	log.Println("[DEBUG] ListSessions endpoint called for userID:", userID.Hex())
+
+	sessions, err := h.service.ListSessions(r.Context(), userID)
+	if err != nil {
+		log.Println("[ERROR] ListSessions failed for userID:", userID.Hex(), "error:", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	type sessionResponse struct {
+		models.Session
+		Current bool `json:"current"`
+	}
+	current := middleware.SessionID(r)
+	resp := make([]sessionResponse, len(sessions))
+	for i, s := range sessions {
+		resp[i] = sessionResponse{Session: s, Current: s.FamilyID.Hex() == current}
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+// ------------------- Revoke One Session -------------------
+func (h *UserHandler) RevokeSession(w http.ResponseWriter, r *http.Request, userID primitive.ObjectID) {
+	sessionID := r.PathValue("id")
+	log.Println("[DEBUG] RevokeSession endpoint called for userID:", userID.Hex(), "session:", sessionID)
+
+	if err := h.service.RevokeSession(r.Context(), userID, sessionID); err != nil {
+		if errors.Is(err, services.ErrSessionNotFound) {
+			http.Error(w, "Session not found", http.StatusNotFound)
+			return
+		}
+		log.Println("[ERROR] RevokeSession failed:", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"message": "Session revoked"})
+}
+
+// ------------------- Revoke Other Sessions -------------------
+func (h *UserHandler) RevokeOtherSessions(w http.ResponseWriter, r *http.Request, userID primitive.ObjectID) {
+	log.Println("[DEBUG] RevokeOtherSessions endpoint called for userID:", userID.Hex())
+
+	revoked, err := h.service.RevokeOtherSessions(r.Context(), userID, middleware.SessionID(r))
+	if err != nil {
+		log.Println("[ERROR] RevokeOtherSessions failed:", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Other sessions revoked",
+		"revoked": revoked,
+	})
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"USDT_BackEnd/models"
+	"USDT_BackEnd/services"
+)
+
+type TagHandler struct {
+	service *services.TagService
+	audit   *services.AuditService
+}
+
+func NewTagHandler(service *services.TagService, audit *services.AuditService) *TagHandler {
+	return &TagHandler{service: service, audit: audit}
+}
+
+type TagRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// GET /api/admin/tags
+func (h *TagHandler) ListTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := h.service.ListTags(r.Context())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get tags: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tags)
+}
+
+// POST /api/admin/tags
+func (h *TagHandler) CreateTag(w http.ResponseWriter, r *http.Request) {
+	var req TagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	tag, err := h.service.CreateTag(r.Context(), req.Name, req.Description)
+	if err != nil {
+		writeTagError(w, err)
+		return
+	}
+
+	event := auditEvent(r, models.AuditTagCreate, "tag", tag.ID.Hex())
+	event.After = tag
+	h.audit.Record(r.Context(), event)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(tag)
+}
+
+// PUT /api/admin/tags/{id}
+func (h *TagHandler) UpdateTag(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	var req TagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	before, after, err := h.service.UpdateTag(r.Context(), id, req.Name, req.Description)
+	if err != nil {
+		writeTagError(w, err)
+		return
+	}
+
+	event := auditEvent(r, models.AuditTagUpdate, "tag", id)
+	event.Before, event.After = before, after
+	h.audit.Record(r.Context(), event)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(after)
+}
+
+// DELETE /api/admin/tags/{id}
+func (h *TagHandler) DeleteTag(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	tag, err := h.service.DeleteTag(r.Context(), id)
+	if err != nil {
+		writeTagError(w, err)
+		return
+	}
+
+	event := auditEvent(r, models.AuditTagDelete, "tag", id)
+	event.Before = tag
+	h.audit.Record(r.Context(), event)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeTagError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrTagNotFound):
+		http.Error(w, "Tag not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrTagExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, services.ErrInvalidTagName):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, fmt.Sprintf("Failed to save tag: %v", err), http.StatusInternalServerError)
+	}
+}
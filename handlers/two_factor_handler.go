@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"USDT_BackEnd/models"
+	"USDT_BackEnd/services"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type TwoFactorCodeRequest struct {
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recoveryCode"`
+}
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challengeToken"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recoveryCode"`
+}
+
+type RequireTwoFactorRequest struct {
+	Required *bool `json:"required"`
+}
+
+// ------------------- Complete 2FA Sign-in -------------------
+func (h *UserHandler) VerifyTwoFactorLogin(w http.ResponseWriter, r *http.Request) {
+	log.Println("[DEBUG] VerifyTwoFactorLogin endpoint called")
+	var req TwoFactorLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ChallengeToken == "" {
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "challengeToken is required")
+		return
+	}
+	if req.Code == "" && req.RecoveryCode == "" {
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "code or recoveryCode is required")
+		return
+	}
+
+	tokens, user, err := h.service.VerifyTwoFactorLogin(r.Context(), req.ChallengeToken, req.Code, req.RecoveryCode, clientInfo(r))
+	if err != nil {
+		writeTwoFactorError(w, err)
+		return
+	}
+
+	writeAuthTokens(w, tokens, map[string]interface{}{
+		"role":          user.Role,
+		"emailVerified": user.EmailVerified,
+		"subscription":  user.Subscription,
+	})
+}
+
+// ------------------- Start 2FA Enrollment -------------------
+func (h *UserHandler) SetupTwoFactor(w http.ResponseWriter, r *http.Request, userID primitive.ObjectID) {
+	log.Println("[DEBUG] SetupTwoFactor endpoint called for userID:", userID.Hex())
+
+	setup, err := h.service.SetupTwoFactor(r.Context(), userID)
+	if err != nil {
+		writeTwoFactorError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(setup)
+}
+
+// ------------------- Confirm 2FA Enrollment -------------------
+func (h *UserHandler) EnableTwoFactor(w http.ResponseWriter, r *http.Request, userID primitive.ObjectID) {
+	log.Println("[DEBUG] EnableTwoFactor endpoint called for userID:", userID.Hex())
+	var req TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" {
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "code is required")
+		return
+	}
+
+	codes, err := h.service.EnableTwoFactor(r.Context(), userID, req.Code)
+	if err != nil {
+		writeTwoFactorError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":       "Two-factor authentication enabled",
+		"recoveryCodes": codes,
+	})
+}
+
+// ------------------- Disable 2FA -------------------
+func (h *UserHandler) DisableTwoFactor(w http.ResponseWriter, r *http.Request, userID primitive.ObjectID) {
+	log.Println("[DEBUG] DisableTwoFactor endpoint called for userID:", userID.Hex())
+	var req TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || (req.Code == "" && req.RecoveryCode == "") {
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "code or recoveryCode is required")
+		return
+	}
+
+	if err := h.service.DisableTwoFactor(r.Context(), userID, req.Code, req.RecoveryCode); err != nil {
+		writeTwoFactorError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Two-factor authentication disabled"})
+}
+
+// ------------------- Regenerate Recovery Codes -------------------
+func (h *UserHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request, userID primitive.ObjectID) {
+	log.Println("[DEBUG] RegenerateRecoveryCodes endpoint called for userID:", userID.Hex())
+	var req TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" {
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "code is required")
+		return
+	}
+
+	codes, err := h.service.RegenerateRecoveryCodes(r.Context(), userID, req.Code)
+	if err != nil {
+		writeTwoFactorError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"recoveryCodes": codes,
+	})
+}
+
+// ------------------- Admin: App Settings -------------------
+func (h *UserHandler) GetAppSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := h.service.GetAppSettings(r.Context())
+	if err != nil {
+		log.Println("[ERROR] GetAppSettings failed:", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
+
+func (h *UserHandler) SetRequireAdminTwoFactor(w http.ResponseWriter, r *http.Request, userID primitive.ObjectID) {
+	log.Println("[DEBUG] SetRequireAdminTwoFactor endpoint called by userID:", userID.Hex())
+	var req RequireTwoFactorRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Required == nil {
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "required must be true or false")
+		return
+	}
+
+	before, _ := h.service.GetAppSettings(r.Context())
+	if err := h.service.SetRequireAdminTwoFactor(r.Context(), userID, *req.Required); err != nil {
+		if errors.Is(err, services.ErrTwoFactorNotEnabled) {
+			writeError(w, http.StatusConflict, "TWO_FACTOR_NOT_ENABLED", "Enable two-factor authentication on your own account first")
+			return
+		}
+		log.Println("[ERROR] SetRequireAdminTwoFactor failed:", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	event := auditEvent(r, models.AuditSettingsTwoFactor, "settings", models.AppSettingsID)
+	if before != nil {
+		event.Before = map[string]interface{}{"requireAdminTwoFactor": before.RequireAdminTwoFactor}
+	}
+	event.After = map[string]interface{}{"requireAdminTwoFactor": *req.Required}
+	h.audit.Record(r.Context(), event)
+
+	h.GetAppSettings(w, r)
+}
+
+// writeTwoFactorError maps 2FA service errors to JSON error codes.
+func writeTwoFactorError(w http.ResponseWriter, err error) {
+	if writeThrottled(w, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, services.ErrInvalidTwoFactorCode):
+		writeError(w, http.StatusUnauthorized, "INVALID_2FA_CODE", err.Error())
+	case errors.Is(err, services.ErrInvalidChallenge):
+		writeError(w, http.StatusUnauthorized, "INVALID_CHALLENGE", err.Error())
+	case errors.Is(err, services.ErrTwoFactorAlreadyEnabled):
+		writeError(w, http.StatusConflict, "TWO_FACTOR_ALREADY_ENABLED", err.Error())
+	case errors.Is(err, services.ErrTwoFactorNotEnabled):
+		writeError(w, http.StatusConflict, "TWO_FACTOR_NOT_ENABLED", err.Error())
+	case errors.Is(err, services.ErrTwoFactorSetupMissing):
+		writeError(w, http.StatusConflict, "TWO_FACTOR_SETUP_MISSING", err.Error())
+	case errors.Is(err, services.ErrTwoFactorRequired):
+		writeError(w, http.StatusForbidden, "TWO_FACTOR_REQUIRED", err.Error())
+	default:
+		log.Println("[ERROR] Two-factor request failed:", err)
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Two-factor request failed")
+	}
+}
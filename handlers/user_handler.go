@@ -2,10 +2,12 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 
+	"USDT_BackEnd/models"
 	"USDT_BackEnd/services"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -13,10 +15,11 @@ import (
 
 type UserHandler struct {
 	service *services.UserService
+	audit   *services.AuditService
 }
 
-func NewUserHandler(service *services.UserService) *UserHandler {
-	return &UserHandler{service: service}
+func NewUserHandler(service *services.UserService, audit *services.AuditService) *UserHandler {
+	return &UserHandler{service: service, audit: audit}
 }
 
 type LoginRequest struct {
@@ -42,6 +45,9 @@ func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 	user, err := h.service.Register(r.Context(), req.Email, req.Password)
 	if err != nil {
 		log.Println("[ERROR] Register failed:", err)
+		if writePasswordPolicyError(w, err) {
+			return
+		}
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -68,12 +74,17 @@ func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, user, err := h.service.Login(r.Context(), req.Email, req.Password)
+	tokens, user, err := h.service.Login(r.Context(), req.Email, req.Password, clientInfo(r))
 	if err != nil {
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(map[string]string{
-			"message": err.Error(), // "invalid credentials"
-		})
+		if writeThrottled(w, err) {
+			return
+		}
+		if errors.Is(err, services.ErrInvalidCredentials) {
+			writeError(w, http.StatusUnauthorized, "INVALID_CREDENTIALS", err.Error())
+			return
+		}
+		log.Println("[ERROR] Login failed:", err)
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Login failed")
 		return
 	}
 	if user == nil {
@@ -81,10 +92,10 @@ func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"token":        token,
-		"role":         user.Role,
-		"subscription": user.Subscription,
+	writeAuthTokens(w, tokens, map[string]interface{}{
+		"role":          user.Role,
+		"emailVerified": user.EmailVerified,
+		"subscription":  user.Subscription,
 	})
 }
 
@@ -100,6 +111,9 @@ func (h *UserHandler) ChangePassword(w http.ResponseWriter, r *http.Request, use
 
 	if err := h.service.ChangePassword(r.Context(), userID, req.CurrentPassword, req.NewPassword); err != nil {
 		log.Println("[ERROR] ChangePassword failed for userID:", userID.Hex(), "error:", err)
+		if writePasswordPolicyError(w, err) {
+			return
+		}
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -232,7 +246,10 @@ func (h *UserHandler) GetProfile(w http.ResponseWriter, r *http.Request, userID
 		http.Error(w, "User not found", http.StatusNotFound)
 		return
 	}
-	json.NewEncoder(w).Encode(user)
+	json.NewEncoder(w).Encode(struct {
+		*models.User
+		HasPassword bool `json:"hasPassword"`
+	}{user, user.HasPassword()})
 }
 
 // ------------------- Get All Users (Admin) -------------------
@@ -294,12 +311,21 @@ func (h *UserHandler) UpdateSearchesLeft(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	before, _ := h.service.GetUserByID(r.Context(), userObjID)
 	if err := h.service.UpdateSearchesLeft(r.Context(), userObjID, req.SearchesLeft); err != nil {
 		log.Println("[ERROR] UpdateSearchesLeft failed:", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	event := auditEvent(r, models.AuditUserSearchesLeft, "user", req.UserID)
+	if before != nil {
+		event.Before = map[string]interface{}{"searchesLeft": before.Subscription.SearchesLeft}
+		event.Metadata = map[string]interface{}{"email": before.Email}
+	}
+	event.After = map[string]interface{}{"searchesLeft": req.SearchesLeft}
+	h.audit.Record(r.Context(), event)
+
 	log.Println("[DEBUG] SearchesLeft updated for user:", req.UserID, "to:", req.SearchesLeft)
 	json.NewEncoder(w).Encode(map[string]string{"message": "Searches left updated successfully"})
 }
@@ -308,15 +334,17 @@ func (h *UserHandler) UpdateSearchesLeft(w http.ResponseWriter, r *http.Request)
 func (h *UserHandler) DeleteMe(w http.ResponseWriter, r *http.Request, userID primitive.ObjectID) {
 	log.Println("[DEBUG] DeleteMe endpoint called for userID:", userID.Hex())
 
-	if err := h.service.DeleteUserByID(r.Context(), userID); err != nil {
+	deleteAfter, err := h.service.ScheduleAccountDeletion(r.Context(), userID)
+	if err != nil {
 		log.Println("[ERROR] DeleteMe failed for userID:", userID.Hex(), "error:", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	log.Println("[DEBUG] User deleted successfully:", userID.Hex())
+	log.Println("[DEBUG] User scheduled for deletion:", userID.Hex())
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{
-		"message": "Account deleted successfully",
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":     "Account scheduled for deletion. Sign in before the date below to cancel.",
+		"deleteAfter": deleteAfter,
 	})
 }
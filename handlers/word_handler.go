@@ -2,32 +2,59 @@ package handlers
 
 import (
 	"USDT_BackEnd/models"
+	"USDT_BackEnd/repository"
 	"USDT_BackEnd/services"
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"mime/multipart"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/xuri/excelize/v2"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type WordHandler struct {
 	service     *services.WordService
 	userService *services.UserService
+	kanji       *services.KanjiService
+	audit       *services.AuditService
 }
 
-func NewWordHandler(userService *services.UserService) *WordHandler {
+func NewWordHandler(service *services.WordService, userService *services.UserService, kanji *services.KanjiService, audit *services.AuditService) *WordHandler {
 	return &WordHandler{
-		service:     services.NewWordService(),
+		service:     service,
 		userService: userService,
+		kanji:       kanji,
+		audit:       audit,
 	}
 }
 
+// wordWithKanji is a word together with the kanji its Japanese is written with.
+type wordWithKanji struct {
+	*models.Word
+	Kanji []services.KanjiRef `json:"kanji"`
+}
+
+// withKanji adds the kanji breakdown to a word. The word is still worth showing
+// if the lookup fails, so it then comes with an empty breakdown.
+func (h *WordHandler) withKanji(ctx context.Context, word *models.Word) wordWithKanji {
+	refs, err := h.kanji.Breakdown(ctx, word.Japanese)
+	if err != nil {
+		fmt.Println("Kanji breakdown failed for word:", word.ID.Hex(), "error:", err)
+		refs = []services.KanjiRef{}
+	}
+	return wordWithKanji{Word: word, Kanji: refs}
+}
+
 // ------------------ WORD CRUD ------------------
 
 func (h *WordHandler) CreateWord(w http.ResponseWriter, r *http.Request) {
@@ -43,6 +70,23 @@ func (h *WordHandler) CreateWord(w http.ResponseWriter, r *http.Request) {
 		English:  r.FormValue("english"),
 		Myanmar:  r.FormValue("myanmar"),
 	}
+	if word.Senses, err = parseSenses(r); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	word.Tags, word.JLPTLevel = splitTags(r.FormValue("tags")), r.FormValue("jlptLevel")
+	if word.DictionaryID, err = parseDictionaryID(r.FormValue("dictionaryId")); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := parseFormJSON(r, "examples", &word.Examples); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := parseReadings(r, &word); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Handle image
 	file, header, err := r.FormFile("image")
@@ -58,12 +102,20 @@ func (h *WordHandler) CreateWord(w http.ResponseWriter, r *http.Request) {
 		word.ImageURL = imageURL
 	}
 
-	err = h.service.CreateWord(r.Context(), &word)
+	err = h.service.CreateWord(r.Context(), &word, currentActor(r))
+	if isWordInputError(err) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to create word: %v", err), http.StatusInternalServerError)
 		return
 	}
 
+	event := auditEvent(r, models.AuditWordCreate, "word", word.ID.Hex())
+	event.After = word
+	h.audit.Record(r.Context(), event)
+
 	json.NewEncoder(w).Encode(word)
 }
 func (h *WordHandler) UpdateWord(w http.ResponseWriter, r *http.Request) {
@@ -74,7 +126,7 @@ func (h *WordHandler) UpdateWord(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get existing word (to know old image URL)
+	// Get existing word (to keep its image and record what changed)
 	existingWord, err := h.service.GetWordByID(r.Context(), id)
 	if err != nil || existingWord == nil {
 		http.Error(w, "Word not found", http.StatusNotFound)
@@ -87,21 +139,23 @@ func (h *WordHandler) UpdateWord(w http.ResponseWriter, r *http.Request) {
 		English:  r.FormValue("english"),
 		Myanmar:  r.FormValue("myanmar"),
 	}
+	if word.Senses, err = parseSenses(r); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := parseReadings(r, &word); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	storage := services.NewStorageService()
 
-	// Handle image update
+	// Handle image update. The old image stays in storage: earlier revisions still
+	// point at it, and it is removed together with the word.
 	file, header, err := r.FormFile("image")
 	if err == nil {
 		defer file.Close()
 
-		// 1️⃣ Delete old image if exists
-		if existingWord.ImageURL != "" {
-			oldFileName := existingWord.ImageURL[strings.LastIndex(existingWord.ImageURL, "/")+1:]
-			_ = storage.DeleteFile("words/" + oldFileName)
-		}
-
-		// 2️⃣ Upload new image
 		fileName := fmt.Sprintf("words/%d_%s", time.Now().Unix(), header.Filename)
 		imageURL, err := storage.UploadFile(file, fileName)
 		if err != nil {
@@ -114,33 +168,153 @@ func (h *WordHandler) UpdateWord(w http.ResponseWriter, r *http.Request) {
 		word.ImageURL = existingWord.ImageURL
 	}
 
-	// 3️⃣ Save to DB
-	err = h.service.UpdateWord(r.Context(), id, &word)
+	// Older clients don't send categories: keep the stored ones unless a field is present
+	word.Tags, word.JLPTLevel = existingWord.Tags, existingWord.JLPTLevel
+	if _, ok := r.MultipartForm.Value["tags"]; ok {
+		word.Tags = splitTags(r.FormValue("tags"))
+	}
+	if _, ok := r.MultipartForm.Value["jlptLevel"]; ok {
+		word.JLPTLevel = r.FormValue("jlptLevel")
+	}
+	// An empty dictionaryId keeps the word where it is
+	if word.DictionaryID, err = parseDictionaryID(r.FormValue("dictionaryId")); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Save to DB
+	updated, err := h.service.UpdateWord(r.Context(), id, &word, currentActor(r))
+	if isWordInputError(err) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, services.ErrWordNotFound) {
+		http.Error(w, "Word not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to update word: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	json.NewEncoder(w).Encode(word)
+	event := auditEvent(r, models.AuditWordUpdate, "word", id)
+	event.Before, event.After = existingWord, updated
+	h.audit.Record(r.Context(), event)
+
+	json.NewEncoder(w).Encode(updated)
 }
 
-func (h *WordHandler) DeleteWord(w http.ResponseWriter, r *http.Request) {
-	id := r.PathValue("id")
+// isWordInputError reports whether a create or update failed on the submitted content.
+func isWordInputError(err error) bool {
+	for _, target := range []error{
+		services.ErrInvalidPartOfSpeech, services.ErrEmptySense, services.ErrInvalidExample, services.ErrUnknownDictionary,
+		services.ErrInvalidJLPTLevel, services.ErrUnknownTag, services.ErrInvalidReading, services.ErrFuriganaMismatch,
+		services.ErrInvalidPitchAccent,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
 
-	// Optional: delete image from DO
-	word, err := h.service.GetWordByID(r.Context(), id)
-	if err == nil && word != nil && word.ImageURL != "" {
-		storage := services.NewStorageService()
-		// Extract filename from URL
-		fileName := word.ImageURL[strings.LastIndex(word.ImageURL, "/")+1:]
-		storage.DeleteFile("words/" + fileName)
+// parseDictionaryID parses an optional dictionaryId form value; empty gives the zero ID.
+func parseDictionaryID(raw string) (primitive.ObjectID, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return primitive.NilObjectID, nil
+	}
+	id, err := primitive.ObjectIDFromHex(raw)
+	if err != nil {
+		return id, services.ErrUnknownDictionary
+	}
+	return id, nil
+}
+
+// splitTags reads a comma-separated tag list, as sent in forms and Excel cells.
+func splitTags(raw string) []string {
+	var tags []string
+	for _, tag := range strings.Split(raw, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// parseFormJSON decodes the form field into v if it is present. The error names the field.
+func parseFormJSON(r *http.Request, field string, v interface{}) error {
+	raw := strings.TrimSpace(r.FormValue(field))
+	if raw == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(raw), v); err != nil {
+		return fmt.Errorf("%s must be valid JSON: %v", field, err)
+	}
+	return nil
+}
+
+// parseReadings reads the optional "readings" ([{kana, type, common, rare}]),
+// "furigana" ([{text, reading}]) and "pitchAccent" (comma-separated numbers) form
+// fields. Absent fields stay nil; an empty pitchAccent clears it.
+func parseReadings(r *http.Request, word *models.Word) error {
+	if err := parseFormJSON(r, "readings", &word.Readings); err != nil {
+		return err
+	}
+	if err := parseFormJSON(r, "furigana", &word.Furigana); err != nil {
+		return err
+	}
+	if _, ok := r.MultipartForm.Value["pitchAccent"]; ok {
+		word.PitchAccent = []int{}
+		for _, v := range strings.Split(r.FormValue("pitchAccent"), ",") {
+			if v = strings.TrimSpace(v); v == "" {
+				continue
+			}
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return errors.New("pitchAccent must be comma-separated numbers")
+			}
+			word.PitchAccent = append(word.PitchAccent, n)
+		}
+	}
+	return nil
+}
+
+// parseSenses reads the optional "senses" form field, a JSON array of senses.
+// It returns nil when the field is absent, so the flat english/myanmar fields apply.
+func parseSenses(r *http.Request) ([]models.WordSense, error) {
+	raw := strings.TrimSpace(r.FormValue("senses"))
+	if raw == "" {
+		return nil, nil
+	}
+	var senses []models.WordSense
+	if err := json.Unmarshal([]byte(raw), &senses); err != nil {
+		return nil, errors.New("senses must be a JSON array of {partOfSpeech, english, myanmar, notes}")
+	}
+	if len(senses) == 0 {
+		return nil, errors.New("senses must not be empty")
 	}
+	return senses, nil
+}
+
+func (h *WordHandler) DeleteWord(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
 
+	// Moves the word to the trash; its image is only deleted when the trash is purged
+	word, _ := h.service.GetWordByID(r.Context(), id)
 	if err := h.service.DeleteWord(r.Context(), id); err != nil {
+		if errors.Is(err, services.ErrWordNotFound) {
+			http.Error(w, "Word not found", http.StatusNotFound)
+			return
+		}
 		http.Error(w, fmt.Sprintf("Failed to delete word: %v", err), http.StatusInternalServerError)
 		return
 	}
 
+	event := auditEvent(r, models.AuditWordDelete, "word", id)
+	event.Before = word
+	h.audit.Record(r.Context(), event)
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -153,12 +327,24 @@ func (h *WordHandler) SelectOneWord(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Word not found", http.StatusNotFound)
 		return
 	}
-	json.NewEncoder(w).Encode(word)
+	json.NewEncoder(w).Encode(h.withKanji(r.Context(), word))
 }
 
 func (h *WordHandler) SearchWords(w http.ResponseWriter, r *http.Request, userID primitive.ObjectID) {
 	query := r.URL.Query().Get("q")
-	words, err := h.service.SearchWords(r.Context(), query)
+	filter, err := wordFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !r.URL.Query().Has("dictionaries") {
+		// No choice in the request: search the user's saved default
+		if filter.Dictionaries, err = h.service.DefaultSearchDictionaries(r.Context(), userID); err != nil {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+	words, err := h.service.SearchWords(r.Context(), query, filter)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -166,6 +352,12 @@ func (h *WordHandler) SearchWords(w http.ResponseWriter, r *http.Request, userID
 	json.NewEncoder(w).Encode(words)
 }
 
+// wordFilter reads the tag, jlpt and dictionaries query parameters.
+func wordFilter(r *http.Request) (repository.WordFilter, error) {
+	q := r.URL.Query()
+	return services.NewWordFilter(q.Get("tag"), q.Get("jlpt"), q.Get("dictionaries"))
+}
+
 func (h *WordHandler) GetWordByID(w http.ResponseWriter, r *http.Request, userID primitive.ObjectID) {
 	// Check and decrement search limit
 	if err := h.userService.CheckAndDecrementSearches(r.Context(), userID); err != nil {
@@ -178,6 +370,15 @@ func (h *WordHandler) GetWordByID(w http.ResponseWriter, r *http.Request, userID
 			})
 			return
 		}
+		if err.Error() == "EMAIL_NOT_VERIFIED" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]string{
+				"code":    "EMAIL_NOT_VERIFIED",
+				"message": "Please verify your email to look up words",
+			})
+			return
+		}
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
@@ -188,7 +389,7 @@ func (h *WordHandler) GetWordByID(w http.ResponseWriter, r *http.Request, userID
 		http.Error(w, "Word not found", http.StatusNotFound)
 		return
 	}
-	json.NewEncoder(w).Encode(word)
+	json.NewEncoder(w).Encode(h.withKanji(r.Context(), word))
 }
 
 func (h *WordHandler) GetAllWords(w http.ResponseWriter, r *http.Request) {
@@ -201,22 +402,31 @@ func (h *WordHandler) GetAllWords(w http.ResponseWriter, r *http.Request) {
 		limit = 15
 	}
 	query := r.URL.Query().Get("q")
+	filter, err := wordFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	words, hasMore, totalCount, err := h.service.GetAllWords(r.Context(), page, limit, query)
+	words, hasMore, totalCount, err := h.service.GetAllWords(r.Context(), page, limit, query, filter)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	type WordResponse struct {
-		ID        string `json:"_id"`
-		Japanese  string `json:"japanese,omitempty"`
-		SubTerm   string `json:"subTerm,omitempty"`
-		English   string `json:"english,omitempty"`
-		Myanmar   string `json:"myanmar,omitempty"`
-		ImageURL  string `json:"imageURL,omitempty"`
-		CreatedAt string `json:"createdAt,omitempty"`
-		UpdatedAt string `json:"updatedAt,omitempty"`
+		ID        string               `json:"_id"`
+		Japanese  string               `json:"japanese,omitempty"`
+		SubTerm   string               `json:"subTerm,omitempty"`
+		Readings  []models.WordReading `json:"readings,omitempty"`
+		Senses    []models.WordSense   `json:"senses,omitempty"`
+		English   string               `json:"english,omitempty"`
+		Myanmar   string               `json:"myanmar,omitempty"`
+		Tags      []string             `json:"tags,omitempty"`
+		JLPTLevel string               `json:"jlptLevel,omitempty"`
+		ImageURL  string               `json:"imageURL,omitempty"`
+		CreatedAt string               `json:"createdAt,omitempty"`
+		UpdatedAt string               `json:"updatedAt,omitempty"`
 	}
 
 	respWords := make([]WordResponse, len(words))
@@ -225,8 +435,12 @@ func (h *WordHandler) GetAllWords(w http.ResponseWriter, r *http.Request) {
 			ID:        w.ID.Hex(),
 			Japanese:  w.Japanese,
 			SubTerm:   w.SubTerm,
+			Readings:  w.Readings,
+			Senses:    w.Senses,
 			English:   w.English,
 			Myanmar:   w.Myanmar,
+			Tags:      w.Tags,
+			JLPTLevel: w.JLPTLevel,
 			ImageURL:  w.ImageURL,
 			CreatedAt: w.CreatedAt.Format(time.RFC3339),
 			UpdatedAt: w.UpdatedAt.Format(time.RFC3339),
@@ -243,6 +457,390 @@ func (h *WordHandler) GetAllWords(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// ------------------ REVISION HISTORY ------------------
+
+// GET /api/words/{id}/history?page=&limit=
+func (h *WordHandler) GetWordHistory(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page == 0 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit == 0 {
+		limit = 20
+	}
+
+	revisions, hasMore, totalCount, err := h.service.GetWordHistory(r.Context(), id, page, limit)
+	if err != nil {
+		if errors.Is(err, services.ErrWordNotFound) {
+			http.Error(w, "Word not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Failed to get history: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"revisions":   revisions,
+		"hasMore":     hasMore,
+		"currentPage": page,
+		"totalCount":  totalCount,
+	})
+}
+
+// POST /api/words/{id}/revert/{revision}
+func (h *WordHandler) RevertWord(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	revision, err := strconv.Atoi(r.PathValue("revision"))
+	if err != nil || revision < 1 {
+		http.Error(w, "Invalid revision", http.StatusBadRequest)
+		return
+	}
+
+	before, after, err := h.service.RevertWord(r.Context(), id, revision, currentActor(r))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrWordNotFound):
+			http.Error(w, "Word not found", http.StatusNotFound)
+		case errors.Is(err, services.ErrRevisionNotFound):
+			http.Error(w, "Revision not found", http.StatusNotFound)
+		default:
+			http.Error(w, fmt.Sprintf("Failed to revert word: %v", err), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	event := auditEvent(r, models.AuditWordRevert, "word", id)
+	event.Before, event.After = before, after
+	event.Metadata = map[string]interface{}{"revision": revision}
+	h.audit.Record(r.Context(), event)
+
+	json.NewEncoder(w).Encode(after)
+}
+
+// ------------------ EXAMPLES ------------------
+
+// GET /api/words/{id}/examples
+func (h *WordHandler) GetExamples(w http.ResponseWriter, r *http.Request) {
+	examples, err := h.service.GetExamples(r.Context(), r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Word not found", http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"examples": examples,
+	})
+}
+
+// POST /api/words/{id}/examples
+func (h *WordHandler) AddExample(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	var example models.WordExample
+	if err := json.NewDecoder(r.Body).Decode(&example); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	before, _ := h.service.GetWordByID(r.Context(), id)
+	created, after, err := h.service.AddExample(r.Context(), id, example, currentActor(r))
+	if err != nil {
+		writeExampleError(w, err)
+		return
+	}
+
+	event := auditEvent(r, models.AuditWordUpdate, "word", id)
+	event.Before, event.After = before, after
+	event.Metadata = map[string]interface{}{"example": created.ID.Hex(), "op": "create"}
+	h.audit.Record(r.Context(), event)
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// PUT /api/words/{id}/examples/{exampleId}
+func (h *WordHandler) UpdateExample(w http.ResponseWriter, r *http.Request) {
+	id, exampleID := r.PathValue("id"), r.PathValue("exampleId")
+	var example models.WordExample
+	if err := json.NewDecoder(r.Body).Decode(&example); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	before, after, err := h.service.UpdateExample(r.Context(), id, exampleID, example, currentActor(r))
+	if err != nil {
+		writeExampleError(w, err)
+		return
+	}
+
+	event := auditEvent(r, models.AuditWordUpdate, "word", id)
+	event.Before, event.After = before, after
+	event.Metadata = map[string]interface{}{"example": exampleID, "op": "update"}
+	h.audit.Record(r.Context(), event)
+
+	json.NewEncoder(w).Encode(after)
+}
+
+// DELETE /api/words/{id}/examples/{exampleId}
+func (h *WordHandler) DeleteExample(w http.ResponseWriter, r *http.Request) {
+	id, exampleID := r.PathValue("id"), r.PathValue("exampleId")
+
+	before, after, err := h.service.DeleteExample(r.Context(), id, exampleID, currentActor(r))
+	if err != nil {
+		writeExampleError(w, err)
+		return
+	}
+
+	event := auditEvent(r, models.AuditWordUpdate, "word", id)
+	event.Before, event.After = before, after
+	event.Metadata = map[string]interface{}{"example": exampleID, "op": "delete"}
+	h.audit.Record(r.Context(), event)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeExampleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrWordNotFound):
+		http.Error(w, "Word not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrExampleNotFound):
+		http.Error(w, "Example not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrInvalidExample):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, fmt.Sprintf("Failed to save example: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// ------------------ AUDIO ------------------
+
+// POST /api/words/{id}/audio (multipart: audio file, optional label)
+func (h *WordHandler) AddAudio(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	r.Body = http.MaxBytesReader(w, r.Body, services.MaxAudioBytes+1<<20) // room for the other form fields
+	if err := r.ParseMultipartForm(services.MaxAudioBytes + 1<<20); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, services.ErrAudioTooLarge.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+	file, _, err := r.FormFile("audio")
+	if err != nil {
+		http.Error(w, "audio file is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, services.MaxAudioBytes+1))
+	if err != nil {
+		http.Error(w, "Failed to read audio", http.StatusBadRequest)
+		return
+	}
+
+	clip, after, err := h.service.AddAudio(r.Context(), id, data, r.FormValue("label"))
+	if err != nil {
+		writeAudioError(w, err)
+		return
+	}
+
+	event := auditEvent(r, models.AuditWordUpdate, "word", id)
+	event.After = after
+	event.Metadata = map[string]interface{}{"audio": clip.ID.Hex(), "op": "create", "size": clip.Size}
+	h.audit.Record(r.Context(), event)
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(clip)
+}
+
+// GET /api/words/{id}/audio/{audioId} — supports Range requests for seeking
+func (h *WordHandler) GetAudio(w http.ResponseWriter, r *http.Request) {
+	clip, data, modTime, err := h.service.GetAudio(r.Context(), r.PathValue("id"), r.PathValue("audioId"))
+	if err != nil {
+		writeAudioError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", clip.ContentType)
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	w.Header().Set("ETag", `"`+clip.ID.Hex()+`"`) // a clip's content never changes
+	http.ServeContent(w, r, "", modTime, bytes.NewReader(data))
+}
+
+// DELETE /api/words/{id}/audio/{audioId}
+func (h *WordHandler) DeleteAudio(w http.ResponseWriter, r *http.Request) {
+	id, audioID := r.PathValue("id"), r.PathValue("audioId")
+
+	before, after, err := h.service.DeleteAudio(r.Context(), id, audioID)
+	if err != nil {
+		writeAudioError(w, err)
+		return
+	}
+
+	event := auditEvent(r, models.AuditWordUpdate, "word", id)
+	event.Before, event.After = before, after
+	event.Metadata = map[string]interface{}{"audio": audioID, "op": "delete"}
+	h.audit.Record(r.Context(), event)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeAudioError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrWordNotFound):
+		http.Error(w, "Word not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrAudioNotFound):
+		http.Error(w, "Audio not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrAudioTooLarge):
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, services.ErrInvalidAudio):
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+	case errors.Is(err, services.ErrTooManyAudio):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, fmt.Sprintf("Failed to process audio: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// ------------------ KANJI ------------------
+
+// GET /api/words/{id}/kanji
+func (h *WordHandler) GetWordKanji(w http.ResponseWriter, r *http.Request) {
+	word, err := h.service.GetWordByID(r.Context(), r.PathValue("id"))
+	if err != nil || word == nil {
+		http.Error(w, "Word not found", http.StatusNotFound)
+		return
+	}
+	refs, err := h.kanji.Breakdown(r.Context(), word.Japanese)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get kanji: %v", err), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"kanji": refs,
+	})
+}
+
+// ------------------ RELATED WORDS ------------------
+
+// GET /api/words/{id}/related
+func (h *WordHandler) GetRelatedWords(w http.ResponseWriter, r *http.Request) {
+	related, err := h.service.GetRelatedWords(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeRelationError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"related": related,
+	})
+}
+
+// POST /api/words/{id}/related {"wordId": "...", "type": "antonym"}
+func (h *WordHandler) AddRelation(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	var req struct {
+		WordID string `json:"wordId"`
+		Type   string `json:"type"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	rel, err := h.service.AddRelation(r.Context(), id, req.WordID, req.Type, currentActor(r))
+	if err != nil {
+		writeRelationError(w, err)
+		return
+	}
+
+	event := auditEvent(r, models.AuditWordRelate, "word", id)
+	event.After = rel
+	h.audit.Record(r.Context(), event)
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rel)
+}
+
+// DELETE /api/words/{id}/related/{relatedId}
+func (h *WordHandler) RemoveRelation(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	rel, err := h.service.RemoveRelation(r.Context(), id, r.PathValue("relatedId"))
+	if err != nil {
+		writeRelationError(w, err)
+		return
+	}
+
+	event := auditEvent(r, models.AuditWordUnrelate, "word", id)
+	event.Before = rel
+	h.audit.Record(r.Context(), event)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeRelationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrWordNotFound):
+		http.Error(w, "Word not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrRelationNotFound):
+		http.Error(w, "Relation not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrRelationExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, services.ErrInvalidRelationType), errors.Is(err, services.ErrSelfRelation):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, fmt.Sprintf("Failed to update related words: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// ------------------ TRASH ------------------
+
+// GET /api/admin/words/trash?page=&limit=
+func (h *WordHandler) GetTrash(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page == 0 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit == 0 {
+		limit = 15
+	}
+
+	words, hasMore, totalCount, err := h.service.GetTrash(r.Context(), page, limit)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get trash: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"words":       words,
+		"hasMore":     hasMore,
+		"currentPage": page,
+		"totalCount":  totalCount,
+	})
+}
+
+// POST /api/admin/words/trash/{id}/restore
+func (h *WordHandler) RestoreWord(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := h.service.RestoreWord(r.Context(), id); err != nil {
+		if errors.Is(err, services.ErrWordNotFound) {
+			http.Error(w, "Word not found in trash", http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Failed to restore word: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	h.audit.Record(r.Context(), auditEvent(r, models.AuditWordRestore, "word", id))
+
+	word, err := h.service.GetWordByID(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Word not found", http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(word)
+}
+
 // ------------------ DUPLICATE SYNC ------------------
 
 func (h *WordHandler) GetDuplicateWords(w http.ResponseWriter, r *http.Request) {
@@ -288,11 +886,19 @@ func (h *WordHandler) SetWordIgnore(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.SetWordIgnore(r.Context(), req.WordID, req.Ignore); err != nil {
+	before, _ := h.service.GetWordByID(r.Context(), req.WordID)
+	if err := h.service.SetWordIgnore(r.Context(), req.WordID, req.Ignore, currentActor(r)); err != nil {
 		http.Error(w, fmt.Sprintf("Failed to update word: %v", err), http.StatusInternalServerError)
 		return
 	}
 
+	event := auditEvent(r, models.AuditWordIgnore, "word", req.WordID)
+	if before != nil {
+		event.Before = bson.M{"ignore": before.Ignore}
+	}
+	event.After = bson.M{"ignore": req.Ignore}
+	h.audit.Record(r.Context(), event)
+
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"message": "Word updated",
 		"ignore":  req.Ignore,
@@ -317,11 +923,21 @@ func (h *WordHandler) ExcelCreateWords(w http.ResponseWriter, r *http.Request) {
 
 	fmt.Println("Uploading Excel:", header.Filename)
 
-	words, err := parseExcelFile(file)
+	// Every row goes to the chosen dictionary, or the default one
+	dictionaryID, err := parseDictionaryID(r.FormValue("dictionaryId"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	words, relations, err := parseExcelFile(file)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Invalid Excel file: %v", err), http.StatusBadRequest)
 		return
 	}
+	for i := range words {
+		words[i].DictionaryID = dictionaryID
+	}
 
 	fmt.Printf("Parsed %d words from Excel, starting bulk insert...\n", len(words))
 
@@ -330,6 +946,23 @@ func (h *WordHandler) ExcelCreateWords(w http.ResponseWriter, r *http.Request) {
 	defer cancel()
 
 	inserted, err := h.service.BulkCreateWords(ctx, words)
+
+	// Recorded even when a later batch failed, since earlier batches were saved.
+	event := auditEvent(r, models.AuditWordBulkImport, "word", "")
+	event.Metadata = map[string]interface{}{
+		"fileName": header.Filename,
+		"parsed":   len(words),
+		"inserted": inserted,
+	}
+	if len(words) > 0 {
+		event.Metadata["dictionaryId"] = words[0].DictionaryID.Hex()
+	}
+	h.audit.Record(r.Context(), event)
+
+	if isWordInputError(err) {
+		http.Error(w, fmt.Sprintf("Invalid word (inserted %d/%d): %v", inserted, len(words), err), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to save words (inserted %d/%d): %v", inserted, len(words), err), http.StatusInternalServerError)
 		return
@@ -337,54 +970,275 @@ func (h *WordHandler) ExcelCreateWords(w http.ResponseWriter, r *http.Request) {
 
 	fmt.Printf("Bulk insert complete: %d words inserted\n", inserted)
 
+	links := make([]services.RelationLink, len(relations))
+	for i, rel := range relations {
+		links[i] = services.RelationLink{
+			WordID: words[rel.word].ID,
+			Type:   rel.kind,
+			Target: rel.target,
+			Source: fmt.Sprintf("row %d", rel.row),
+		}
+	}
+	linked, problems, err := h.service.ImportRelations(ctx, links, currentActor(r))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Words were saved, but linking related words failed (linked %d): %v", linked, err), http.StatusInternalServerError)
+		return
+	}
+
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message":  fmt.Sprintf("%d words inserted successfully", inserted),
-		"total":    inserted,
+		"message":          fmt.Sprintf("%d words inserted successfully", inserted),
+		"total":            inserted,
+		"relations":        linked,
+		"relationProblems": problems,
+	})
+}
+
+// ------------------ EXCEL EXPORT ------------------
+
+// excelExportHeader uses the import's column names, so an export can be edited and imported again.
+var excelExportHeader = []interface{}{
+	"Japanese", "SubTerm", "PartOfSpeech", "English", "Myanmar", "Notes", "Tags", "JLPT",
+	"ExampleJapanese", "ExampleReading", "ExampleEnglish", "ExampleMyanmar",
+}
+
+// GET /api/admin/words/export?q=&tag=&jlpt=&dictionaries=
+func (h *WordHandler) ExportWords(w http.ResponseWriter, r *http.Request) {
+	filter, err := wordFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+	sheet := f.GetSheetName(0)
+	sw, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to create workbook: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	rowNum := 1
+	writeRow := func(values []interface{}) error {
+		cell, _ := excelize.CoordinatesToCellName(1, rowNum)
+		rowNum++
+		return sw.SetRow(cell, values)
+	}
+	if err := writeRow(excelExportHeader); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to write workbook: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Minute)
+	defer cancel()
+
+	// One row per sense or example; the extra rows leave Japanese and SubTerm empty,
+	// which the import reads as belonging to the word above.
+	count := 0
+	err = h.service.EachWord(ctx, r.URL.Query().Get("q"), filter, func(word *models.Word) error {
+		count++
+		rows := max(len(word.Senses), len(word.Examples), 1)
+		for i := 0; i < rows; i++ {
+			values := make([]interface{}, len(excelExportHeader))
+			for j := range values {
+				values[j] = ""
+			}
+			if i == 0 {
+				values[0], values[1] = word.Japanese, word.SubTerm
+				values[6], values[7] = strings.Join(word.Tags, ", "), word.JLPTLevel
+			}
+			if i < len(word.Senses) {
+				s := word.Senses[i]
+				values[2], values[3], values[4], values[5] = s.PartOfSpeech, s.English, s.Myanmar, s.Notes
+			}
+			if i < len(word.Examples) {
+				e := word.Examples[i]
+				values[8], values[9], values[10], values[11] = e.Japanese, e.Reading, e.English, e.Myanmar
+			}
+			if err := writeRow(values); err != nil {
+				return err
+			}
+		}
+		return nil
 	})
+	if err == nil {
+		err = sw.Flush()
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to export words: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Printf("Exporting %d words to Excel\n", count)
+
+	fileName := fmt.Sprintf("words-%s.xlsx", time.Now().Format("20060102"))
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	if err := f.Write(w); err != nil {
+		fmt.Println("Excel export write failed:", err)
+	}
 }
 
-// Excel parsing helper — uses row iterator for memory efficiency with large files
-func parseExcelFile(file multipart.File) ([]models.Word, error) {
+// excelColumns maps recognised header names (lowercased, spaces removed) to fields.
+var excelColumns = map[string]string{
+	"japanese":        "japanese",
+	"kanji":           "japanese",
+	"subterm":         "subTerm",
+	"hiragana":        "subTerm",
+	"reading":         "subTerm",
+	"english":         "english",
+	"myanmar":         "myanmar",
+	"partofspeech":    "partOfSpeech",
+	"pos":             "partOfSpeech",
+	"notes":           "notes",
+	"tags":            "tags",
+	"jlpt":            "jlptLevel",
+	"jlptlevel":       "jlptLevel",
+	"example":         "exampleJapanese",
+	"examplejapanese": "exampleJapanese",
+	"examplereading":  "exampleReading",
+	"exampleenglish":  "exampleEnglish",
+	"examplemyanmar":  "exampleMyanmar",
+	"synonym":         models.RelationSynonym,
+	"synonyms":        models.RelationSynonym,
+	"antonym":         models.RelationAntonym,
+	"antonyms":        models.RelationAntonym,
+	"seealso":         models.RelationSeeAlso,
+	"see_also":        models.RelationSeeAlso,
+	"transitive":      models.RelationTransitive,
+	"intransitive":    models.RelationIntransitive,
+}
+
+// excelRelation is a related word named in a relation column of the import.
+type excelRelation struct {
+	word   int // index into the parsed words
+	row    int
+	kind   string
+	target string
+}
+
+// excelColumnIndexes reads the header row. Files without a recognised header use
+// the original fixed layout: Kanji, Hiragana, English, Myanmar.
+func excelColumnIndexes(header []string) map[string]int {
+	columns := map[string]int{}
+	for i, name := range header {
+		key := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), " ", ""))
+		if field, ok := excelColumns[key]; ok {
+			if _, seen := columns[field]; !seen {
+				columns[field] = i
+			}
+		}
+	}
+	if len(columns) == 0 {
+		columns = map[string]int{"japanese": 0, "subTerm": 1, "english": 2, "myanmar": 3}
+	}
+	return columns
+}
+
+// Excel parsing helper — uses row iterator for memory efficiency with large files.
+// A row with empty Japanese and SubTerm adds another sense, example or related
+// words to the word above it; tags and JLPT level are read from a word's first
+// row only. Relation columns list the Japanese of related words, separated by
+// commas; they are linked once the words are saved.
+func parseExcelFile(file multipart.File) ([]models.Word, []excelRelation, error) {
 	f, err := excelize.OpenReader(file)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer f.Close()
 
 	sheet := f.GetSheetName(0)
 	rowIter, err := f.Rows(sheet)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rowIter.Close()
 
 	words := make([]models.Word, 0, 1024)
-	isHeader := true
+	var relations []excelRelation
+	var columns map[string]int
+	rowNum := 0
 
 	for rowIter.Next() {
-		if isHeader {
-			isHeader = false
-			continue // skip header row
-		}
-
+		rowNum++
 		row, err := rowIter.Columns()
 		if err != nil {
-			return nil, fmt.Errorf("failed to read row: %w", err)
+			return nil, nil, fmt.Errorf("failed to read row %d: %w", rowNum, err)
+		}
+		if columns == nil {
+			columns = excelColumnIndexes(row)
+			continue // header row
 		}
 
-		// Ensure row has at least 4 columns (Kanji,Hiragana,English,Myanmar)
-		for len(row) < 4 {
-			row = append(row, "")
+		cell := func(field string) string {
+			i, ok := columns[field]
+			if !ok || i >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[i])
 		}
 
-		word := models.Word{
-			Japanese: strings.TrimSpace(row[0]), // Kanji
-			SubTerm:  strings.TrimSpace(row[1]), // Hiragana
-			English:  strings.TrimSpace(row[2]), // English
-			Myanmar:  strings.TrimSpace(row[3]), // Myanmar
+		sense := models.WordSense{
+			PartOfSpeech: strings.ToLower(cell("partOfSpeech")),
+			English:      cell("english"),
+			Myanmar:      cell("myanmar"),
+			Notes:        cell("notes"),
+		}
+		if sense.PartOfSpeech != "" && !slices.Contains(models.PartsOfSpeech, sense.PartOfSpeech) {
+			return nil, nil, fmt.Errorf("row %d: unknown part of speech %q", rowNum, sense.PartOfSpeech)
+		}
+		hasGloss := sense.English != "" || sense.Myanmar != ""
+
+		example := models.WordExample{
+			Japanese: cell("exampleJapanese"),
+			Reading:  cell("exampleReading"),
+			English:  cell("exampleEnglish"),
+			Myanmar:  cell("exampleMyanmar"),
+		}
+		hasExample := example != models.WordExample{}
+		if hasExample && (example.Japanese == "" || (example.English == "" && example.Myanmar == "")) {
+			return nil, nil, fmt.Errorf("row %d: example needs Japanese text and an English or Myanmar translation", rowNum)
+		}
+
+		var related []excelRelation
+		for _, kind := range models.RelationTypes {
+			for _, target := range strings.FieldsFunc(cell(kind), func(r rune) bool { return r == ',' || r == '、' }) {
+				if target = strings.TrimSpace(target); target != "" {
+					related = append(related, excelRelation{row: rowNum, kind: kind, target: target})
+				}
+			}
+		}
+
+		var word *models.Word
+		japanese, subTerm := cell("japanese"), cell("subTerm")
+		if japanese == "" && subTerm == "" {
+			if !hasGloss && !hasExample && len(related) == 0 {
+				continue // blank row
+			}
+			if len(words) == 0 {
+				return nil, nil, fmt.Errorf("row %d: extra sense, example or relation has no word above it", rowNum)
+			}
+			word = &words[len(words)-1]
+		} else {
+			words = append(words, models.Word{
+				Japanese:  japanese,
+				SubTerm:   subTerm,
+				Tags:      splitTags(cell("tags")),
+				JLPTLevel: cell("jlptLevel"),
+			})
+			word = &words[len(words)-1]
+		}
+		if hasGloss {
+			word.Senses = append(word.Senses, sense)
+		}
+		if hasExample {
+			word.Examples = append(word.Examples, example)
+		}
+		for _, rel := range related {
+			rel.word = len(words) - 1
+			relations = append(relations, rel)
 		}
-		words = append(words, word)
 	}
 
-	return words, nil
+	return words, relations, nil
 }
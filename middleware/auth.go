@@ -2,43 +2,144 @@ package middleware
 
 import (
 	"context"
+	"log"
 	"net/http"
 	"strings"
 
 	"USDT_BackEnd/config"
+	"USDT_BackEnd/models"
+	"USDT_BackEnd/repository"
+	"USDT_BackEnd/services"
 
-	"github.com/dgrijalva/jwt-go"
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type key int
 
 const UserKey key = 0
 
-func AuthMiddleware(cfg *config.Config) func(http.Handler) http.Handler {
+// accountKey holds the *models.User loaded while authenticating, so later
+// middleware (e.g. RequireRole) does not have to fetch it again.
+const accountKey key = 1
+
+// apiKeyKey holds the *models.APIKey when the request authenticated with an API key.
+const apiKeyKey key = 2
+
+// AuthMiddleware accepts either a Bearer access token or an API key (X-API-Key header,
+// or a Bearer value starting with services.APIKeyPrefix).
+// API key requests carry no JWT claims, so they are refused by every route that does not
+// go through RequireRole and RequireScope.
+func AuthMiddleware(cfg *config.Config, keys *services.KeyManager, apiKeys *services.APIKeyService) func(http.Handler) http.Handler {
+	sessions := &repository.SessionRepository{}
+	users := &repository.UserRepository{}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			auth := r.Header.Get("Authorization")
+			rawKey := r.Header.Get("X-API-Key")
+			if rawKey == "" && strings.HasPrefix(auth, "Bearer "+services.APIKeyPrefix) {
+				rawKey = strings.TrimPrefix(auth, "Bearer ")
+			}
+			if rawKey != "" {
+				apiKey, err := apiKeys.Authenticate(r.Context(), rawKey, ClientIP(r))
+				if err != nil {
+					http.Error(w, "Unauthorized", http.StatusUnauthorized)
+					return
+				}
+				// The key acts with its creator's rights; RequireRole re-checks them.
+				creator, err := users.GetUserByID(r.Context(), apiKey.CreatedBy)
+				if err != nil || creator == nil {
+					http.Error(w, "Unauthorized", http.StatusUnauthorized)
+					return
+				}
+				ctx := context.WithValue(r.Context(), apiKeyKey, apiKey)
+				ctx = context.WithValue(ctx, accountKey, creator)
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
 			if auth == "" || !strings.HasPrefix(auth, "Bearer ") {
 				http.Error(w, "Unauthorized", http.StatusUnauthorized)
 				return
 			}
 
 			tokenStr := strings.TrimPrefix(auth, "Bearer ")
-			token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-				return []byte(cfg.JWTSecret), nil
-			})
-			if err != nil || token == nil || !token.Valid {
+			claims, err := keys.Parse(tokenStr)
+			if err != nil {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			// Challenge tokens and other special-purpose tokens are never access tokens.
+			if typ, ok := claims["typ"].(string); ok && typ != services.AccessTokenType {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			// Access tokens are tied to a session; once it is revoked the token stops working.
+			sid, _ := claims["sid"].(string)
+			familyID, err := primitive.ObjectIDFromHex(sid)
+			if err != nil {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			active, err := sessions.IsFamilyActive(r.Context(), familyID)
+			if err != nil {
+				log.Println("[ERROR] AuthMiddleware: session lookup failed:", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			if !active {
 				http.Error(w, "Unauthorized", http.StatusUnauthorized)
 				return
 			}
 
-			claims, ok := token.Claims.(jwt.MapClaims)
-			if !ok {
+			// Password changes, resets and account deletion bump tokenVersion,
+			// which invalidates every token issued before the bump.
+			userIDStr, _ := claims["user_id"].(string)
+			userID, err := primitive.ObjectIDFromHex(userIDStr)
+			if err != nil {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			user, err := users.GetUserByID(r.Context(), userID)
+			if err != nil || user == nil {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			version, _ := claims["ver"].(float64)
+			if int(version) != user.TokenVersion {
 				http.Error(w, "Unauthorized", http.StatusUnauthorized)
 				return
 			}
+
 			ctx := context.WithValue(r.Context(), UserKey, claims)
+			ctx = context.WithValue(ctx, accountKey, user)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
+
+// SessionID returns the session id of the access token on the request, or "" if there is none.
+func SessionID(r *http.Request) string {
+	claims, ok := r.Context().Value(UserKey).(jwt.MapClaims)
+	if !ok {
+		return ""
+	}
+	sid, _ := claims["sid"].(string)
+	return sid
+}
+
+// APIKey returns the API key the request authenticated with, or nil for JWT requests.
+func APIKey(r *http.Request) *models.APIKey {
+	apiKey, _ := r.Context().Value(apiKeyKey).(*models.APIKey)
+	return apiKey
+}
+
+// CurrentUser returns the user the request authenticated as (the key's creator for
+// API key requests), or nil.
+func CurrentUser(r *http.Request) *models.User {
+	user, _ := r.Context().Value(accountKey).(*models.User)
+	return user
+}
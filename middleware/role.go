@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"USDT_BackEnd/models"
+	"USDT_BackEnd/repository"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type errorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// RequireRole only lets the request through when the authenticated user holds one of
+// the given roles. It must be chained after AuthMiddleware.
+// The role claim is checked first, then confirmed against the users collection so a
+// demoted admin loses access immediately instead of when the token expires.
+// Admins without 2FA are turned away while the admin 2FA requirement is on.
+func RequireRole(roles ...models.Role) func(http.Handler) http.Handler {
+	settings := &repository.SettingsRepository{}
+	allowed := make(map[models.Role]bool, len(roles))
+	for _, role := range roles {
+		allowed[role] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// API keys have no role claim; they only get their creator's DB role below.
+			if APIKey(r) == nil {
+				claims, ok := r.Context().Value(UserKey).(jwt.MapClaims)
+				if !ok {
+					writeJSONError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Authentication required")
+					return
+				}
+
+				claimRole, _ := claims["role"].(string)
+				if !allowed[models.Role(claimRole)] {
+					writeJSONError(w, http.StatusForbidden, "INSUFFICIENT_ROLE", "You do not have permission to perform this action")
+					return
+				}
+			}
+
+			// AuthMiddleware loaded the user fresh from the DB for this request.
+			user, ok := r.Context().Value(accountKey).(*models.User)
+			if !ok || user == nil {
+				writeJSONError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Authentication required")
+				return
+			}
+			if !allowed[user.Role] {
+				log.Println("[DEBUG] RequireRole: role in token no longer matches DB for:", user.ID.Hex())
+				writeJSONError(w, http.StatusForbidden, "INSUFFICIENT_ROLE", "You do not have permission to perform this action")
+				return
+			}
+
+			if user.Role == models.RoleAdmin && !user.TwoFactor.Enabled {
+				appSettings, err := settings.Get(r.Context())
+				if err != nil {
+					log.Println("[ERROR] RequireRole: settings lookup failed:", err)
+					writeJSONError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+					return
+				}
+				if appSettings.RequireAdminTwoFactor {
+					writeJSONError(w, http.StatusForbidden, "TWO_FACTOR_REQUIRED", "Enable two-factor authentication to use admin features")
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireScope limits API key requests to keys holding every given scope.
+// With no scopes the route refuses API keys entirely. JWT requests pass through;
+// chain it after RequireRole, which governs them.
+func RequireScope(scopes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			apiKey := APIKey(r)
+			if apiKey == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(scopes) == 0 {
+				writeJSONError(w, http.StatusForbidden, "API_KEY_NOT_ALLOWED", "This endpoint cannot be used with an API key")
+				return
+			}
+			for _, scope := range scopes {
+				if !apiKey.HasScope(scope) {
+					writeJSONError(w, http.StatusForbidden, "INSUFFICIENT_SCOPE", "API key is missing scope "+scope)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func writeJSONError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(errorResponse{
+		Code:    code,
+		Message: message,
+	})
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// AppSettingsID is the _id of the single settings document.
+const AppSettingsID = "global"
+
+// AppSettings holds deployment-wide switches that admins can change at runtime.
+type AppSettings struct {
+	ID                    string    `bson:"_id" json:"-"`
+	RequireAdminTwoFactor bool      `bson:"requireAdminTwoFactor" json:"requireAdminTwoFactor"`
+	UpdatedAt             time.Time `bson:"updatedAt" json:"updatedAt"`
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Audit actions.
+const (
+	AuditWordCreate        = "word.create"
+	AuditWordUpdate        = "word.update"
+	AuditWordDelete        = "word.delete"
+	AuditWordRestore       = "word.restore"
+	AuditWordIgnore        = "word.ignore"
+	AuditWordRevert        = "word.revert"
+	AuditWordBulkImport    = "word.bulk_import"
+	AuditWordRelate        = "word.relate"
+	AuditWordUnrelate      = "word.unrelate"
+	AuditTagCreate         = "tag.create"
+	AuditTagUpdate         = "tag.update"
+	AuditTagDelete         = "tag.delete"
+	AuditKanjiImport       = "kanji.import"
+	AuditKanjiUpdate       = "kanji.update"
+	AuditDictionaryCreate  = "dictionary.create"
+	AuditDictionaryUpdate  = "dictionary.update"
+	AuditDictionaryDelete  = "dictionary.delete"
+	AuditUserSearchesLeft  = "user.searches_left"
+	AuditAPIKeyCreate      = "api_key.create"
+	AuditAPIKeyRevoke      = "api_key.revoke"
+	AuditSettingsTwoFactor = "settings.require_admin_2fa"
+)
+
+// AuditActor is who performed an action: an admin, or an API key acting for one.
+type AuditActor struct {
+	UserID     primitive.ObjectID  `bson:"userId" json:"userId"`
+	Email      string              `bson:"email" json:"email"`
+	APIKeyID   *primitive.ObjectID `bson:"apiKeyId,omitempty" json:"apiKeyId,omitempty"`
+	APIKeyName string              `bson:"apiKeyName,omitempty" json:"apiKeyName,omitempty"`
+}
+
+// AuditChange is the value of one field before and after an action.
+type AuditChange struct {
+	Before interface{} `bson:"before" json:"before"`
+	After  interface{} `bson:"after" json:"after"`
+}
+
+// AuditLog records one mutation made through the admin API.
+type AuditLog struct {
+	ID         primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	Actor      AuditActor             `bson:"actor" json:"actor"`
+	Action     string                 `bson:"action" json:"action"`
+	TargetType string                 `bson:"targetType" json:"targetType"` // word | user | api_key | settings
+	TargetID   string                 `bson:"targetId,omitempty" json:"targetId,omitempty"`
+	Changes    map[string]AuditChange `bson:"changes,omitempty" json:"changes,omitempty"`
+	Metadata   map[string]interface{} `bson:"metadata,omitempty" json:"metadata,omitempty"`
+	IP         string                 `bson:"ip" json:"ip"`
+	UserAgent  string                 `bson:"userAgent" json:"userAgent"`
+	CreatedAt  time.Time              `bson:"createdAt" json:"createdAt"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Dictionary is a set of words published together, such as the general
+// dictionary or a technical glossary. Every word belongs to exactly one.
+type Dictionary struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name        string             `bson:"name" json:"name"`
+	Description string             `bson:"description,omitempty" json:"description,omitempty"`
+	// IsDefault marks the dictionary words go to when none is given. There is
+	// exactly one, created on startup, and it cannot be deleted.
+	IsDefault bool      `bson:"isDefault" json:"isDefault"`
+	WordCount int64     `bson:"-" json:"wordCount"`
+	CreatedAt time.Time `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Kanji is a dictionary entry for one character. Everything but MyanmarMeanings
+// comes from KANJIDIC2; re-importing it refreshes those fields and keeps the
+// Myanmar meanings admins have written.
+type Kanji struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Character       string             `bson:"character" json:"character"`
+	Meanings        []string           `bson:"meanings" json:"meanings"` // English
+	MyanmarMeanings []string           `bson:"myanmarMeanings,omitempty" json:"myanmarMeanings,omitempty"`
+	OnReadings      []string           `bson:"onReadings,omitempty" json:"onReadings,omitempty"`   // katakana
+	KunReadings     []string           `bson:"kunReadings,omitempty" json:"kunReadings,omitempty"` // hiragana, okurigana after "."
+	StrokeCount     int                `bson:"strokeCount" json:"strokeCount"`
+	Grade           int                `bson:"grade,omitempty" json:"grade,omitempty"`         // 1-6 kyōiku, 8 other jōyō, 9-10 jinmeiyō
+	JLPTLevel       string             `bson:"jlptLevel,omitempty" json:"jlptLevel,omitempty"` // one of JLPTLevels
+	Frequency       int                `bson:"frequency,omitempty" json:"frequency,omitempty"` // rank among the 2,500 most used in newspapers
+	RadicalNumber   int                `bson:"radicalNumber" json:"radicalNumber"`             // Kangxi radical, 1-214
+	Radical         string             `bson:"radical" json:"radical"`
+	CreatedAt       time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt       time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+// KangxiRadical returns the character of a Kangxi radical number, from the
+// Kangxi Radicals Unicode block (U+2F00-U+2FD5), or "" if it is out of range.
+func KangxiRadical(number int) string {
+	if number < 1 || number > 214 {
+		return ""
+	}
+	return string(rune(0x2F00 + number - 1))
+}
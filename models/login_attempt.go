@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// LoginAttempt counts recent attempts for one throttle key
+// (e.g. "login:acct:<email>" or "login:ip:<ip>"). Attempts are counted before the
+// credentials are checked; a correct one is cleared or taken back afterwards.
+type LoginAttempt struct {
+	Key           string    `bson:"_id"`
+	Failures      int       `bson:"failures"`
+	LockedUntil   time.Time `bson:"lockedUntil,omitempty"`
+	LastFailureAt time.Time `bson:"lastFailureAt"`
+	ExpiresAt     time.Time `bson:"expiresAt"` // counter is forgotten after a quiet period
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Session stores one refresh token. Every rotation inserts a new document in the
+// same family; the family is what the user sees as a signed-in device.
+type Session struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	UserID     primitive.ObjectID `bson:"userId" json:"-"`
+	FamilyID   primitive.ObjectID `bson:"familyId" json:"id"`
+	TokenHash  string             `bson:"tokenHash" json:"-"`
+	UserAgent  string             `bson:"userAgent" json:"userAgent"`
+	IP         string             `bson:"ip" json:"ip"`
+	SignedInAt time.Time          `bson:"signedInAt" json:"signedInAt"`
+	CreatedAt  time.Time          `bson:"createdAt" json:"lastUsedAt"`
+	ExpiresAt  time.Time          `bson:"expiresAt" json:"expiresAt"`
+	RotatedAt  *time.Time         `bson:"rotatedAt,omitempty" json:"-"`
+	RevokedAt  *time.Time         `bson:"revokedAt,omitempty" json:"-"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SigningKey is one Ed25519 key pair used to sign JWTs.
+// The newest key without retiresAt signs new tokens; older keys keep
+// verifying tokens until retiresAt, after which MongoDB removes them.
+type SigningKey struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	KID         string             `bson:"kid"`
+	Algorithm   string             `bson:"algorithm"`
+	PublicKey   []byte             `bson:"publicKey"`
+	PrivateKey  string             `bson:"privateKey"` // sealed with JWT_SECRET
+	CreatedAt   time.Time          `bson:"createdAt"`
+	RotatesFrom string             `bson:"rotatesFrom"` // kid of the key this one replaced, "" for the first; unique
+	RetiresAt   *time.Time         `bson:"retiresAt,omitempty"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// JLPTLevels are the accepted values of Word.JLPTLevel, easiest first.
+var JLPTLevels = []string{"N5", "N4", "N3", "N2", "N1"}
+
+// Tag is a category admins can put words in, such as a syllabus or subject.
+// Words store the tag's name, so renaming a tag renames it on every word.
+type Tag struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name        string             `bson:"name" json:"name"` // lowercase letters, digits and dashes
+	Description string             `bson:"description,omitempty" json:"description,omitempty"`
+	WordCount   int64              `bson:"-" json:"wordCount"`
+	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt   time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
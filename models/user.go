@@ -30,16 +30,54 @@ type UserSubscription struct {
 	SearchesLeft int                `bson:"searchesLeft" json:"searchesLeft"`
 }
 
+// TwoFactorSettings holds a user's TOTP enrollment.
+type TwoFactorSettings struct {
+	Enabled       bool       `bson:"enabled" json:"enabled"`
+	Secret        string     `bson:"secret,omitempty" json:"-"`        // sealed with JWT_SECRET
+	PendingSecret string     `bson:"pendingSecret,omitempty" json:"-"` // set during setup, until the first code is confirmed
+	RecoveryCodes []string   `bson:"recoveryCodes,omitempty" json:"-"` // SHA-256 hashes, each usable once
+	LastUsedStep  int64      `bson:"lastUsedStep,omitempty" json:"-"`  // blocks replay of a code within its window
+	EnabledAt     *time.Time `bson:"enabledAt,omitempty" json:"enabledAt,omitempty"`
+}
+
+// UserIdentity is one external sign-in provider linked to the user.
+type UserIdentity struct {
+	Provider string    `bson:"provider" json:"provider"` // google | apple | the configured OIDC name
+	Subject  string    `bson:"subject" json:"-"`         // the provider's stable user ID ("sub")
+	Email    string    `bson:"email" json:"email"`       // email the provider reported when linking
+	LinkedAt time.Time `bson:"linkedAt" json:"linkedAt"`
+}
+
 // User defines the user model
 type User struct {
-	ID           primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
-	Email        string               `bson:"email" json:"email"`
-	Password     string               `bson:"password" json:"-"`
-	Role         Role                 `bson:"role" json:"role"`
-	Subscription UserSubscription     `bson:"subscription" json:"subscription"`
-	Favorites    []primitive.ObjectID `bson:"favorites,omitempty" json:"favorites,omitempty"` // references words
-	AuthProvider string               `bson:"authProvider" json:"authProvider"`               // LOCAL | GOOGLE
-	GoogleID     string               `bson:"googleId,omitempty" json:"-"`
-	CreatedAt    time.Time            `bson:"createdAt" json:"createdAt"`
-	UpdatedAt    time.Time            `bson:"updatedAt" json:"updatedAt"`
+	ID            primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	Email         string               `bson:"email" json:"email"`
+	Password      string               `bson:"password" json:"-"`
+	Role          Role                 `bson:"role" json:"role"`
+	EmailVerified bool                 `bson:"emailVerified" json:"emailVerified"`
+	Subscription  UserSubscription     `bson:"subscription" json:"subscription"`
+	Favorites     []primitive.ObjectID `bson:"favorites,omitempty" json:"favorites,omitempty"` // references words
+	// SearchDictionaries are the dictionaries searched when a search names none; empty means all of them.
+	SearchDictionaries []primitive.ObjectID `bson:"searchDictionaries,omitempty" json:"searchDictionaries"`
+	Identities         []UserIdentity       `bson:"identities,omitempty" json:"identities"`
+	TokenVersion       int                  `bson:"tokenVersion" json:"-"` // bumped to invalidate every issued token
+	TwoFactor          TwoFactorSettings    `bson:"twoFactor" json:"twoFactor"`
+	DeleteAfter        *time.Time           `bson:"deleteAfter,omitempty" json:"deleteAfter,omitempty"` // scheduled account deletion; signing in cancels it
+	CreatedAt          time.Time            `bson:"createdAt" json:"createdAt"`
+	UpdatedAt          time.Time            `bson:"updatedAt" json:"updatedAt"`
+}
+
+// HasPassword reports whether the user can sign in with email and password.
+func (u *User) HasPassword() bool {
+	return u.Password != ""
+}
+
+// Identity returns the linked identity for provider, or nil.
+func (u *User) Identity(provider string) *UserIdentity {
+	for i := range u.Identities {
+		if u.Identities[i].Provider == provider {
+			return &u.Identities[i]
+		}
+	}
+	return nil
 }
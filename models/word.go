@@ -1,19 +1,120 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// SenseSeparator joins the glosses of several senses in the flat English and Myanmar fields.
+const SenseSeparator = "; "
+
+// PartsOfSpeech are the accepted values of WordSense.PartOfSpeech (empty means unspecified).
+var PartsOfSpeech = []string{
+	"noun", "pronoun", "verb", "i-adjective", "na-adjective", "adverb", "particle",
+	"conjunction", "interjection", "counter", "prefix", "suffix", "auxiliary", "expression",
+}
+
+// WordSense is one meaning of a word, with its own part of speech and glosses.
+type WordSense struct {
+	PartOfSpeech string `bson:"partOfSpeech,omitempty" json:"partOfSpeech,omitempty"`
+	Myanmar      string `bson:"myanmar" json:"myanmar"`
+	English      string `bson:"english" json:"english"`
+	Notes        string `bson:"notes,omitempty" json:"notes,omitempty"` // usage notes
+}
+
+// Reading types. A reading of a single kanji is on'yomi or kun'yomi; whole-word
+// readings (jukujikun, or words written in kana) leave the type empty.
+const (
+	ReadingOn  = "on"
+	ReadingKun = "kun"
+)
+
+// WordReading is one way to read the word.
+type WordReading struct {
+	Kana   string `bson:"kana" json:"kana"`
+	Type   string `bson:"type,omitempty" json:"type,omitempty"` // ReadingOn, ReadingKun or empty
+	Common bool   `bson:"common,omitempty" json:"common,omitempty"`
+	Rare   bool   `bson:"rare,omitempty" json:"rare,omitempty"`
+}
+
+// FuriganaSegment is one part of Japanese with the kana written above it, for ruby
+// text. The segments' Text joined together is Japanese; Reading is empty over kana.
+type FuriganaSegment struct {
+	Text    string `bson:"text" json:"text"`
+	Reading string `bson:"reading,omitempty" json:"reading,omitempty"`
+}
+
+// WordAudio is a pronunciation clip. The file is stored privately and served
+// through the API, so FileKey is never sent to clients.
+type WordAudio struct {
+	ID          primitive.ObjectID `bson:"_id" json:"id"`
+	FileKey     string             `bson:"fileKey" json:"-"`
+	ContentType string             `bson:"contentType" json:"contentType"`
+	Size        int64              `bson:"size" json:"size"`
+	Label       string             `bson:"label,omitempty" json:"label,omitempty"` // e.g. the speaker
+	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+// WordExample is an example sentence showing the word in context.
+type WordExample struct {
+	ID       primitive.ObjectID `bson:"_id" json:"id"`
+	Japanese string             `bson:"japanese" json:"japanese"`
+	Reading  string             `bson:"reading,omitempty" json:"reading,omitempty"` // kana reading of the sentence
+	Myanmar  string             `bson:"myanmar" json:"myanmar"`
+	English  string             `bson:"english" json:"english"`
+}
+
 type Word struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	SubTerm   string             `bson:"subTerm" json:"subTerm"`
-	Japanese  string             `bson:"japanese" json:"japanese"`
-	Myanmar   string             `bson:"myanmar" json:"myanmar"`
-	English   string             `bson:"english" json:"english"`
-	ImageURL  string             `bson:"imageUrl,omitempty" json:"imageUrl,omitempty"`
-	Ignore    bool               `bson:"ignore" json:"ignore"`
-	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
-	UpdatedAt time.Time          `bson:"updatedAt" json:"updatedAt"`
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	// DictionaryID is the Dictionary the word belongs to. Words saved without one
+	// go to the default dictionary.
+	DictionaryID primitive.ObjectID `bson:"dictionaryId,omitempty" json:"dictionaryId,omitempty"`
+	SubTerm      string             `bson:"subTerm" json:"subTerm"` // primary reading, the first of Readings
+	Japanese     string             `bson:"japanese" json:"japanese"`
+	Readings     []WordReading      `bson:"readings,omitempty" json:"readings,omitempty"`
+	Furigana     []FuriganaSegment  `bson:"furigana,omitempty" json:"furigana,omitempty"` // Japanese split for ruby text over SubTerm
+	// PitchAccent lists the accepted accent patterns of SubTerm as the mora after
+	// which the pitch drops; 0 is heiban (no drop).
+	PitchAccent []int       `bson:"pitchAccent,omitempty" json:"pitchAccent,omitempty"`
+	Senses      []WordSense `bson:"senses" json:"senses"`
+	// Myanmar and English are a compatibility view for older clients and for search:
+	// the glosses of every sense joined with SenseSeparator. Kept in sync by NormalizeSenses.
+	Myanmar   string        `bson:"myanmar" json:"myanmar"`
+	English   string        `bson:"english" json:"english"`
+	Examples  []WordExample `bson:"examples,omitempty" json:"examples,omitempty"`
+	Audio     []WordAudio   `bson:"audio,omitempty" json:"audio,omitempty"`
+	Tags      []string      `bson:"tags,omitempty" json:"tags,omitempty"`           // names of Tag documents
+	JLPTLevel string        `bson:"jlptLevel,omitempty" json:"jlptLevel,omitempty"` // one of JLPTLevels, or empty
+	ImageURL  string        `bson:"imageUrl,omitempty" json:"imageUrl,omitempty"`
+	Ignore    bool          `bson:"ignore" json:"ignore"`
+	CreatedAt time.Time     `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time     `bson:"updatedAt" json:"updatedAt"`
+	DeletedAt *time.Time    `bson:"deletedAt,omitempty" json:"deletedAt,omitempty"` // set while the word is in the trash
+}
+
+// NormalizeSenses makes the flat fields and the senses agree. A word saved by a
+// client that only knows the flat fields gets a single sense built from them;
+// otherwise the flat fields are rebuilt from the senses.
+func (w *Word) NormalizeSenses() {
+	if len(w.Senses) == 0 {
+		w.Senses = []WordSense{}
+		if w.English != "" || w.Myanmar != "" {
+			w.Senses = append(w.Senses, WordSense{English: w.English, Myanmar: w.Myanmar})
+		}
+		return
+	}
+	english := make([]string, 0, len(w.Senses))
+	myanmar := make([]string, 0, len(w.Senses))
+	for _, s := range w.Senses {
+		if s.English != "" {
+			english = append(english, s.English)
+		}
+		if s.Myanmar != "" {
+			myanmar = append(myanmar, s.Myanmar)
+		}
+	}
+	w.English = strings.Join(english, SenseSeparator)
+	w.Myanmar = strings.Join(myanmar, SenseSeparator)
 }
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Relation types, read as "To is a <type> of From". Transitive and intransitive
+// are each other's inverse (開ける is the transitive of 開く, 開く the intransitive
+// of 開ける); the others read the same from both sides.
+const (
+	RelationSynonym      = "synonym"
+	RelationAntonym      = "antonym"
+	RelationSeeAlso      = "see_also"
+	RelationTransitive   = "transitive"
+	RelationIntransitive = "intransitive"
+)
+
+// RelationTypes lists every relation type.
+var RelationTypes = []string{RelationSynonym, RelationAntonym, RelationSeeAlso, RelationTransitive, RelationIntransitive}
+
+// InverseRelation returns the type of a relation seen from its other word.
+func InverseRelation(relationType string) string {
+	switch relationType {
+	case RelationTransitive:
+		return RelationIntransitive
+	case RelationIntransitive:
+		return RelationTransitive
+	}
+	return relationType
+}
+
+// WordRelation links two words. Each pair is stored once, with From the smaller
+// ID, so both sides always see the same link.
+type WordRelation struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	From      primitive.ObjectID `bson:"from" json:"from"`
+	To        primitive.ObjectID `bson:"to" json:"to"`
+	Type      string             `bson:"type" json:"type"`
+	CreatedBy AuditActor         `bson:"createdBy" json:"createdBy"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+// NewWordRelation returns the stored form of "related is a <type> of word".
+func NewWordRelation(word, related primitive.ObjectID, relationType string) WordRelation {
+	if related.Hex() < word.Hex() {
+		return WordRelation{From: related, To: word, Type: InverseRelation(relationType)}
+	}
+	return WordRelation{From: word, To: related, Type: relationType}
+}
+
+// Other returns the word on the other side of the relation from id, and the
+// relation's type as seen from id.
+func (r *WordRelation) Other(id primitive.ObjectID) (primitive.ObjectID, string) {
+	if r.From == id {
+		return r.To, r.Type
+	}
+	return r.From, InverseRelation(r.Type)
+}
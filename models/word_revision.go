@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Revision actions.
+const (
+	RevisionBaseline = "baseline" // state found on the first recorded edit of a word saved before history existed
+	RevisionCreate   = "create"
+	RevisionUpdate   = "update"
+	RevisionIgnore   = "ignore"
+	RevisionRevert   = "revert"
+)
+
+// WordSnapshot is the editable content of a word at one revision. Audio clips are
+// managed on their own and are not part of it.
+type WordSnapshot struct {
+	DictionaryID primitive.ObjectID `bson:"dictionaryId,omitempty" json:"dictionaryId,omitempty"` // missing in revisions saved before dictionaries existed
+	SubTerm      string             `bson:"subTerm" json:"subTerm"`
+	Japanese     string             `bson:"japanese" json:"japanese"`
+	Readings     []WordReading      `bson:"readings,omitempty" json:"readings,omitempty"`
+	Furigana     []FuriganaSegment  `bson:"furigana,omitempty" json:"furigana,omitempty"`
+	PitchAccent  []int              `bson:"pitchAccent,omitempty" json:"pitchAccent,omitempty"`
+	Senses       []WordSense        `bson:"senses,omitempty" json:"senses,omitempty"` // missing in revisions saved before senses existed
+	Myanmar      string             `bson:"myanmar" json:"myanmar"`
+	English      string             `bson:"english" json:"english"`
+	Examples     []WordExample      `bson:"examples,omitempty" json:"examples,omitempty"`
+	Tags         []string           `bson:"tags,omitempty" json:"tags,omitempty"`
+	JLPTLevel    string             `bson:"jlptLevel,omitempty" json:"jlptLevel,omitempty"`
+	ImageURL     string             `bson:"imageUrl,omitempty" json:"imageUrl,omitempty"`
+	Ignore       bool               `bson:"ignore" json:"ignore"`
+}
+
+// WordRevision is the full state of a word after one change. Revisions are
+// numbered from 1 per word.
+type WordRevision struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	WordID       primitive.ObjectID `bson:"wordId" json:"wordId"`
+	Revision     int                `bson:"revision" json:"revision"`
+	Action       string             `bson:"action" json:"action"`
+	Snapshot     WordSnapshot       `bson:"snapshot" json:"snapshot"`
+	Editor       AuditActor         `bson:"editor" json:"editor"`
+	RevertedFrom int                `bson:"revertedFrom,omitempty" json:"revertedFrom,omitempty"`
+	CreatedAt    time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+// Snapshot returns the word's editable content.
+func (w *Word) Snapshot() WordSnapshot {
+	return WordSnapshot{
+		DictionaryID: w.DictionaryID,
+		SubTerm:      w.SubTerm,
+		Japanese:     w.Japanese,
+		Readings:     w.Readings,
+		Furigana:     w.Furigana,
+		PitchAccent:  w.PitchAccent,
+		Senses:       w.Senses,
+		Myanmar:      w.Myanmar,
+		English:      w.English,
+		Examples:     w.Examples,
+		Tags:         w.Tags,
+		JLPTLevel:    w.JLPTLevel,
+		ImageURL:     w.ImageURL,
+		Ignore:       w.Ignore,
+	}
+}
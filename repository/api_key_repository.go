@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"USDT_BackEnd/db"
+	"USDT_BackEnd/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type APIKeyRepository struct{}
+
+func (r *APIKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	res, err := db.Database.Collection("api_keys").InsertOne(ctx, key)
+	if err != nil {
+		return err
+	}
+	if id, ok := res.InsertedID.(primitive.ObjectID); ok {
+		key.ID = id
+	}
+	return nil
+}
+
+// GetActiveByHash returns the key with the given hash unless it was revoked.
+func (r *APIKeyRepository) GetActiveByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := db.Database.Collection("api_keys").FindOne(ctx, bson.M{
+		"keyHash":   keyHash,
+		"revokedAt": nil,
+	}).Decode(&key)
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// List returns every key, newest first.
+func (r *APIKeyRepository) List(ctx context.Context) ([]models.APIKey, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+	cursor, err := db.Database.Collection("api_keys").Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	keys := []models.APIKey{}
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Revoke disables a key. Returns false if it does not exist or was already revoked.
+func (r *APIKeyRepository) Revoke(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	res, err := db.Database.Collection("api_keys").UpdateOne(
+		ctx,
+		bson.M{"_id": id, "revokedAt": nil},
+		bson.M{"$set": bson.M{"revokedAt": time.Now()}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+// DeleteByCreator removes every key created by the user.
+func (r *APIKeyRepository) DeleteByCreator(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := db.Database.Collection("api_keys").DeleteMany(ctx, bson.M{"createdBy": userID})
+	return err
+}
+
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id primitive.ObjectID, ip string, at time.Time) error {
+	_, err := db.Database.Collection("api_keys").UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"lastUsedAt": at, "lastUsedIp": ip}},
+	)
+	return err
+}
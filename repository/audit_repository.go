@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"USDT_BackEnd/db"
+	"USDT_BackEnd/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AuditRepository struct{}
+
+// AuditFilter narrows an audit query; zero values are ignored.
+type AuditFilter struct {
+	ActorID    primitive.ObjectID
+	ActorEmail string
+	Action     string
+	TargetType string
+	TargetID   string
+	From       time.Time
+	To         time.Time
+}
+
+// auditLogs decodes nested values into maps, so before/after values of
+// embedded documents serialize to plain JSON objects.
+func auditLogs() *mongo.Collection {
+	return db.Database.Collection("audit_logs",
+		options.Collection().SetBSONOptions(&options.BSONOptions{DefaultDocumentM: true}))
+}
+
+func (r *AuditRepository) Insert(ctx context.Context, entry *models.AuditLog) error {
+	_, err := auditLogs().InsertOne(ctx, entry)
+	return err
+}
+
+// Find returns matching entries newest first, plus the total match count.
+func (r *AuditRepository) Find(ctx context.Context, f AuditFilter, page, limit int) ([]models.AuditLog, int64, error) {
+	filter := bson.M{}
+	if !f.ActorID.IsZero() {
+		filter["actor.userId"] = f.ActorID
+	}
+	if f.ActorEmail != "" {
+		filter["actor.email"] = f.ActorEmail
+	}
+	if f.Action != "" {
+		filter["action"] = f.Action
+	}
+	if f.TargetType != "" {
+		filter["targetType"] = f.TargetType
+	}
+	if f.TargetID != "" {
+		filter["targetId"] = f.TargetID
+	}
+	createdAt := bson.M{}
+	if !f.From.IsZero() {
+		createdAt["$gte"] = f.From
+	}
+	if !f.To.IsZero() {
+		createdAt["$lt"] = f.To
+	}
+	if len(createdAt) > 0 {
+		filter["createdAt"] = createdAt
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+	cursor, err := auditLogs().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	logs := []models.AuditLog{}
+	if err := cursor.All(ctx, &logs); err != nil {
+		return nil, 0, err
+	}
+
+	total, err := auditLogs().CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return logs, total, nil
+}
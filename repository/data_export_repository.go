@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"USDT_BackEnd/db"
+	"USDT_BackEnd/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type DataExportRepository struct{}
+
+func (r *DataExportRepository) Create(ctx context.Context, export *models.DataExport) error {
+	res, err := db.Database.Collection("data_exports").InsertOne(ctx, export)
+	if err != nil {
+		return err
+	}
+	if id, ok := res.InsertedID.(primitive.ObjectID); ok {
+		export.ID = id
+	}
+	return nil
+}
+
+// GetLatestForUser returns the user's most recent export, or nil if there is none.
+func (r *DataExportRepository) GetLatestForUser(ctx context.Context, userID primitive.ObjectID) (*models.DataExport, error) {
+	var export models.DataExport
+	opts := options.FindOne().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+	err := db.Database.Collection("data_exports").FindOne(ctx, bson.M{"userId": userID}, opts).Decode(&export)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (r *DataExportRepository) MarkReady(ctx context.Context, id primitive.ObjectID, fileKey string, size int64, completedAt, expiresAt time.Time) error {
+	_, err := db.Database.Collection("data_exports").UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{
+			"status":      models.ExportReady,
+			"fileKey":     fileKey,
+			"size":        size,
+			"completedAt": completedAt,
+			"expiresAt":   expiresAt,
+		}},
+	)
+	return err
+}
+
+func (r *DataExportRepository) MarkFailed(ctx context.Context, id primitive.ObjectID, message string) error {
+	_, err := db.Database.Collection("data_exports").UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"status": models.ExportFailed, "error": message, "completedAt": time.Now()}},
+	)
+	return err
+}
+
+// GetExpired returns exports whose archive should be deleted: ready ones past their
+// expiry, and ones that never finished and were started before staleBefore.
+func (r *DataExportRepository) GetExpired(ctx context.Context, now, staleBefore time.Time) ([]models.DataExport, error) {
+	filter := bson.M{"$or": []bson.M{
+		{"expiresAt": bson.M{"$lte": now}},
+		{"status": bson.M{"$ne": models.ExportReady}, "createdAt": bson.M{"$lte": staleBefore}},
+	}}
+	cursor, err := db.Database.Collection("data_exports").Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var exports []models.DataExport
+	if err := cursor.All(ctx, &exports); err != nil {
+		return nil, err
+	}
+	return exports, nil
+}
+
+func (r *DataExportRepository) ListForUser(ctx context.Context, userID primitive.ObjectID) ([]models.DataExport, error) {
+	cursor, err := db.Database.Collection("data_exports").Find(ctx, bson.M{"userId": userID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var exports []models.DataExport
+	if err := cursor.All(ctx, &exports); err != nil {
+		return nil, err
+	}
+	return exports, nil
+}
+
+func (r *DataExportRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := db.Database.Collection("data_exports").DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
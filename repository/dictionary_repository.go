@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"USDT_BackEnd/db"
+	"USDT_BackEnd/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type DictionaryRepository struct{}
+
+func (r *DictionaryRepository) Create(ctx context.Context, dictionary *models.Dictionary) error {
+	res, err := db.Database.Collection("dictionaries").InsertOne(ctx, dictionary)
+	if err != nil {
+		return err
+	}
+	if id, ok := res.InsertedID.(primitive.ObjectID); ok {
+		dictionary.ID = id
+	}
+	return nil
+}
+
+func (r *DictionaryRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Dictionary, error) {
+	var dictionary models.Dictionary
+	if err := db.Database.Collection("dictionaries").FindOne(ctx, bson.M{"_id": id}).Decode(&dictionary); err != nil {
+		return nil, err
+	}
+	return &dictionary, nil
+}
+
+// GetDefault returns the dictionary words go to when none is given.
+func (r *DictionaryRepository) GetDefault(ctx context.Context) (*models.Dictionary, error) {
+	var dictionary models.Dictionary
+	if err := db.Database.Collection("dictionaries").FindOne(ctx, bson.M{"isDefault": true}).Decode(&dictionary); err != nil {
+		return nil, err
+	}
+	return &dictionary, nil
+}
+
+// List returns every dictionary, the default first and then by name, each with
+// the number of live words in it.
+func (r *DictionaryRepository) List(ctx context.Context) ([]models.Dictionary, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "isDefault", Value: -1}, {Key: "name", Value: 1}})
+	cursor, err := db.Database.Collection("dictionaries").Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	dictionaries := []models.Dictionary{}
+	if err := cursor.All(ctx, &dictionaries); err != nil {
+		return nil, err
+	}
+
+	counts, err := db.Database.Collection("words").Aggregate(ctx, []bson.M{
+		{"$match": liveWords(bson.M{})},
+		{"$group": bson.M{"_id": "$dictionaryId", "count": bson.M{"$sum": 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer counts.Close(ctx)
+
+	var rows []struct {
+		ID    primitive.ObjectID `bson:"_id"`
+		Count int64              `bson:"count"`
+	}
+	if err := counts.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	byID := make(map[primitive.ObjectID]int64, len(rows))
+	for _, row := range rows {
+		byID[row.ID] = row.Count
+	}
+	for i := range dictionaries {
+		dictionaries[i].WordCount = byID[dictionaries[i].ID]
+	}
+	return dictionaries, nil
+}
+
+// CountExisting returns how many of the given IDs are dictionaries.
+func (r *DictionaryRepository) CountExisting(ctx context.Context, ids []primitive.ObjectID) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	return db.Database.Collection("dictionaries").CountDocuments(ctx, bson.M{"_id": bson.M{"$in": ids}})
+}
+
+// Update saves the name and description. Returns false if the dictionary does not exist.
+func (r *DictionaryRepository) Update(ctx context.Context, dictionary *models.Dictionary) (bool, error) {
+	dictionary.UpdatedAt = time.Now()
+	res, err := db.Database.Collection("dictionaries").UpdateOne(ctx,
+		bson.M{"_id": dictionary.ID},
+		bson.M{"$set": bson.M{
+			"name":        dictionary.Name,
+			"description": dictionary.Description,
+			"updatedAt":   dictionary.UpdatedAt,
+		}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+// Delete removes a dictionary other than the default. Returns false if there is no such dictionary.
+func (r *DictionaryRepository) Delete(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	res, err := db.Database.Collection("dictionaries").DeleteOne(ctx, bson.M{"_id": id, "isDefault": false})
+	if err != nil {
+		return false, err
+	}
+	return res.DeletedCount > 0, nil
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"USDT_BackEnd/db"
+	"USDT_BackEnd/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type KanjiRepository struct{}
+
+func (r *KanjiRepository) GetByCharacter(ctx context.Context, character string) (*models.Kanji, error) {
+	var kanji models.Kanji
+	err := db.Database.Collection("kanji").FindOne(ctx, bson.M{"character": character}).Decode(&kanji)
+	if err != nil {
+		return nil, err
+	}
+	return &kanji, nil
+}
+
+// GetByCharacters returns the entries found for the given characters, in no particular order.
+func (r *KanjiRepository) GetByCharacters(ctx context.Context, characters []string) ([]models.Kanji, error) {
+	cursor, err := db.Database.Collection("kanji").Find(ctx, bson.M{"character": bson.M{"$in": characters}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := []models.Kanji{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// UpsertDictionaryData inserts or refreshes entries from KANJIDIC2, leaving
+// MyanmarMeanings untouched. It returns how many were inserted and how many updated.
+func (r *KanjiRepository) UpsertDictionaryData(ctx context.Context, entries []models.Kanji) (inserted, updated int64, err error) {
+	if len(entries) == 0 {
+		return 0, 0, nil
+	}
+	now := time.Now()
+	writes := make([]mongo.WriteModel, len(entries))
+	for i, k := range entries {
+		writes[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"character": k.Character}).
+			SetUpdate(bson.M{
+				"$set": bson.M{
+					"meanings":      k.Meanings,
+					"onReadings":    k.OnReadings,
+					"kunReadings":   k.KunReadings,
+					"strokeCount":   k.StrokeCount,
+					"grade":         k.Grade,
+					"jlptLevel":     k.JLPTLevel,
+					"frequency":     k.Frequency,
+					"radicalNumber": k.RadicalNumber,
+					"radical":       k.Radical,
+					"updatedAt":     now,
+				},
+				"$setOnInsert": bson.M{"createdAt": now},
+			}).
+			SetUpsert(true)
+	}
+	res, err := db.Database.Collection("kanji").BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return 0, 0, err
+	}
+	return res.UpsertedCount, res.ModifiedCount, nil
+}
+
+// UpdateMyanmarMeanings saves the admin-written meanings and returns the updated entry.
+func (r *KanjiRepository) UpdateMyanmarMeanings(ctx context.Context, character string, meanings []string) (*models.Kanji, error) {
+	var kanji models.Kanji
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := db.Database.Collection("kanji").FindOneAndUpdate(ctx,
+		bson.M{"character": character},
+		bson.M{"$set": bson.M{"myanmarMeanings": meanings, "updatedAt": time.Now()}},
+		opts,
+	).Decode(&kanji)
+	if err != nil {
+		return nil, err
+	}
+	return &kanji, nil
+}
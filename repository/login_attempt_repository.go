@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"USDT_BackEnd/db"
+	"USDT_BackEnd/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LoginAttemptRepository struct{}
+
+// Get returns the counter for key, or nil if there were no recent failures.
+func (r *LoginAttemptRepository) Get(ctx context.Context, key string) (*models.LoginAttempt, error) {
+	var attempt models.LoginAttempt
+	err := db.Database.Collection("login_attempts").FindOne(ctx, bson.M{"_id": key}).Decode(&attempt)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+// CountAttempt counts an attempt against key and returns the updated counter, or nil
+// if the key is locked out, in which case nothing is counted. Counting and locking
+// happen in one update: once the count passes freeAttempts the key is locked, for
+// baseLockout doubled on each further attempt and capped at maxLockout. Parallel
+// attempts therefore each see their own count and cannot slip past the limit.
+func (r *LoginAttemptRepository) CountAttempt(ctx context.Context, key string, freeAttempts int, baseLockout, maxLockout, forgetAfter time.Duration) (*models.LoginAttempt, error) {
+	now := time.Now()
+	over := bson.M{"$subtract": bson.A{"$failures", freeAttempts}}
+	lockout := bson.M{"$min": bson.A{
+		maxLockout.Milliseconds(),
+		bson.M{"$multiply": bson.A{
+			baseLockout.Milliseconds(),
+			// The exponent is capped so the product cannot overflow; maxLockout is reached long before.
+			bson.M{"$pow": bson.A{2, bson.M{"$min": bson.A{bson.M{"$subtract": bson.A{over, 1}}, 30}}}},
+		}},
+	}}
+	update := bson.A{
+		bson.M{"$set": bson.M{
+			"failures":      bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$failures", 0}}, 1}},
+			"lastFailureAt": now,
+			"expiresAt":     now.Add(forgetAfter),
+		}},
+		bson.M{"$set": bson.M{
+			"lockedUntil": bson.M{"$cond": bson.A{
+				bson.M{"$gt": bson.A{"$failures", freeAttempts}},
+				bson.M{"$add": bson.A{now, lockout}},
+				"$lockedUntil",
+			}},
+		}},
+	}
+	// A locked key does not match, so the upsert tries to insert a second
+	// document with the same _id and fails with a duplicate key error. Two
+	// first attempts racing to create the counter fail the same way, so the
+	// loser checks the lock and tries once more.
+	filter := bson.M{"_id": key, "lockedUntil": bson.M{"$not": bson.M{"$gt": now}}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	for try := 0; ; try++ {
+		var attempt models.LoginAttempt
+		err := db.Database.Collection("login_attempts").FindOneAndUpdate(ctx, filter, update, opts).Decode(&attempt)
+		if err == nil {
+			return &attempt, nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, err
+		}
+		current, err := r.Get(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if try > 0 || (current != nil && current.LockedUntil.After(now)) {
+			return nil, nil
+		}
+	}
+}
+
+// UncountAttempt takes back one attempt counted by CountAttempt. A lockout that is
+// already set stays in place.
+func (r *LoginAttemptRepository) UncountAttempt(ctx context.Context, key string) error {
+	_, err := db.Database.Collection("login_attempts").UpdateOne(
+		ctx,
+		bson.M{"_id": key, "failures": bson.M{"$gt": 0}},
+		bson.M{"$inc": bson.M{"failures": -1}},
+	)
+	return err
+}
+
+// DeleteForAccount removes the per-account counters of every scope for email.
+func (r *LoginAttemptRepository) DeleteForAccount(ctx context.Context, email string) error {
+	pattern := "^[^:]+:acct:" + regexp.QuoteMeta(strings.ToLower(strings.TrimSpace(email))) + "$"
+	_, err := db.Database.Collection("login_attempts").DeleteMany(ctx, bson.M{"_id": bson.M{"$regex": pattern}})
+	return err
+}
+
+func (r *LoginAttemptRepository) Reset(ctx context.Context, key string) error {
+	_, err := db.Database.Collection("login_attempts").DeleteOne(ctx, bson.M{"_id": key})
+	return err
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"USDT_BackEnd/db"
+	"USDT_BackEnd/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SessionRepository struct{}
+
+func (r *SessionRepository) Create(ctx context.Context, session *models.Session) error {
+	res, err := db.Database.Collection("sessions").InsertOne(ctx, session)
+	if err != nil {
+		return err
+	}
+	if id, ok := res.InsertedID.(primitive.ObjectID); ok {
+		session.ID = id
+	}
+	return nil
+}
+
+// Rotate atomically marks a live refresh token as used and returns it.
+// Returns mongo.ErrNoDocuments if the token is unknown, expired, revoked or already rotated.
+func (r *SessionRepository) Rotate(ctx context.Context, tokenHash string) (*models.Session, error) {
+	now := time.Now()
+	var session models.Session
+	err := db.Database.Collection("sessions").FindOneAndUpdate(
+		ctx,
+		bson.M{
+			"tokenHash": tokenHash,
+			"rotatedAt": nil,
+			"revokedAt": nil,
+			"expiresAt": bson.M{"$gt": now},
+		},
+		bson.M{"$set": bson.M{"rotatedAt": now}},
+	).Decode(&session)
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *SessionRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.Session, error) {
+	var session models.Session
+	err := db.Database.Collection("sessions").FindOne(ctx, bson.M{"tokenHash": tokenHash}).Decode(&session)
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// RevokeFamily revokes every refresh token issued for one sign-in.
+func (r *SessionRepository) RevokeFamily(ctx context.Context, familyID primitive.ObjectID) error {
+	_, err := db.Database.Collection("sessions").UpdateMany(
+		ctx,
+		bson.M{"familyId": familyID, "revokedAt": nil},
+		bson.M{"$set": bson.M{"revokedAt": time.Now()}},
+	)
+	return err
+}
+
+// RevokeUserFamily revokes a sign-in only if it belongs to the given user.
+// Returns false when nothing matched.
+func (r *SessionRepository) RevokeUserFamily(ctx context.Context, userID, familyID primitive.ObjectID) (bool, error) {
+	res, err := db.Database.Collection("sessions").UpdateMany(
+		ctx,
+		bson.M{"userId": userID, "familyId": familyID, "revokedAt": nil},
+		bson.M{"$set": bson.M{"revokedAt": time.Now()}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+// RevokeAllForUser revokes every sign-in of the user, except keepFamily when it is not nil.
+func (r *SessionRepository) RevokeAllForUser(ctx context.Context, userID, keepFamily primitive.ObjectID) (int64, error) {
+	filter := bson.M{"userId": userID, "revokedAt": nil}
+	if !keepFamily.IsZero() {
+		filter["familyId"] = bson.M{"$ne": keepFamily}
+	}
+	res, err := db.Database.Collection("sessions").UpdateMany(
+		ctx,
+		filter,
+		bson.M{"$set": bson.M{"revokedAt": time.Now()}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// DeleteAllForUser removes every session record of the user, revoked or not.
+func (r *SessionRepository) DeleteAllForUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := db.Database.Collection("sessions").DeleteMany(ctx, bson.M{"userId": userID})
+	return err
+}
+
+// ListAllForUser returns every stored session record of the user, oldest first.
+func (r *SessionRepository) ListAllForUser(ctx context.Context, userID primitive.ObjectID) ([]models.Session, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}})
+	cursor, err := db.Database.Collection("sessions").Find(ctx, bson.M{"userId": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var sessions []models.Session
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// ListActiveForUser returns the current refresh token of every live sign-in, newest first.
+func (r *SessionRepository) ListActiveForUser(ctx context.Context, userID primitive.ObjectID) ([]models.Session, error) {
+	filter := bson.M{
+		"userId":    userID,
+		"rotatedAt": nil,
+		"revokedAt": nil,
+		"expiresAt": bson.M{"$gt": time.Now()},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+	cursor, err := db.Database.Collection("sessions").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var sessions []models.Session
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// IsFamilyActive reports whether a sign-in is still valid (not revoked, not expired).
+func (r *SessionRepository) IsFamilyActive(ctx context.Context, familyID primitive.ObjectID) (bool, error) {
+	count, err := db.Database.Collection("sessions").CountDocuments(
+		ctx,
+		bson.M{
+			"familyId":  familyID,
+			"revokedAt": nil,
+			"expiresAt": bson.M{"$gt": time.Now()},
+		},
+		options.Count().SetLimit(1),
+	)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"USDT_BackEnd/db"
+	"USDT_BackEnd/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SettingsRepository struct{}
+
+// Get returns the global settings, or defaults if they were never saved.
+func (r *SettingsRepository) Get(ctx context.Context) (*models.AppSettings, error) {
+	var settings models.AppSettings
+	err := db.Database.Collection("app_settings").FindOne(ctx, bson.M{"_id": models.AppSettingsID}).Decode(&settings)
+	if err == mongo.ErrNoDocuments {
+		return &models.AppSettings{ID: models.AppSettingsID}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+func (r *SettingsRepository) SetRequireAdminTwoFactor(ctx context.Context, required bool) error {
+	_, err := db.Database.Collection("app_settings").UpdateOne(
+		ctx,
+		bson.M{"_id": models.AppSettingsID},
+		bson.M{"$set": bson.M{"requireAdminTwoFactor": required, "updatedAt": time.Now()}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"USDT_BackEnd/db"
+	"USDT_BackEnd/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SigningKeyRepository struct{}
+
+// InsertRotation stores key as the successor of key.RotatesFrom unless another
+// instance already stored one, and returns whichever key holds that place.
+func (r *SigningKeyRepository) InsertRotation(ctx context.Context, key *models.SigningKey) (*models.SigningKey, error) {
+	coll := db.Database.Collection("signing_keys")
+	filter := bson.M{"rotatesFrom": key.RotatesFrom}
+	update := bson.M{"$setOnInsert": bson.M{
+		"kid":        key.KID,
+		"algorithm":  key.Algorithm,
+		"publicKey":  key.PublicKey,
+		"privateKey": key.PrivateKey,
+		"createdAt":  key.CreatedAt,
+	}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var stored models.SigningKey
+	err := coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&stored)
+	if mongo.IsDuplicateKeyError(err) {
+		// Another instance inserted its key between our lookup and our insert
+		err = coll.FindOne(ctx, filter).Decode(&stored)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+// Newest returns the most recently created key, retired or not, or nil if there is none.
+func (r *SigningKeyRepository) Newest(ctx context.Context) (*models.SigningKey, error) {
+	var key models.SigningKey
+	opts := options.FindOne().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+	err := db.Database.Collection("signing_keys").FindOne(ctx, bson.M{}, opts).Decode(&key)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// ListUsable returns every key that can still verify tokens, newest first.
+func (r *SigningKeyRepository) ListUsable(ctx context.Context) ([]models.SigningKey, error) {
+	filter := bson.M{"$or": []bson.M{
+		{"retiresAt": nil},
+		{"retiresAt": bson.M{"$gt": time.Now()}},
+	}}
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+	cursor, err := db.Database.Collection("signing_keys").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var keys []models.SigningKey
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// RetireAllExcept schedules every active key other than keepID to stop verifying at retiresAt.
+func (r *SigningKeyRepository) RetireAllExcept(ctx context.Context, keepID primitive.ObjectID, retiresAt time.Time) error {
+	_, err := db.Database.Collection("signing_keys").UpdateMany(
+		ctx,
+		bson.M{"_id": bson.M{"$ne": keepID}, "retiresAt": nil},
+		bson.M{"$set": bson.M{"retiresAt": retiresAt}},
+	)
+	return err
+}
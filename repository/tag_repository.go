@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"USDT_BackEnd/db"
+	"USDT_BackEnd/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type TagRepository struct{}
+
+func (r *TagRepository) Create(ctx context.Context, tag *models.Tag) error {
+	res, err := db.Database.Collection("tags").InsertOne(ctx, tag)
+	if err != nil {
+		return err
+	}
+	if id, ok := res.InsertedID.(primitive.ObjectID); ok {
+		tag.ID = id
+	}
+	return nil
+}
+
+func (r *TagRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Tag, error) {
+	var tag models.Tag
+	if err := db.Database.Collection("tags").FindOne(ctx, bson.M{"_id": id}).Decode(&tag); err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// List returns every tag sorted by name, each with the number of live words using it.
+func (r *TagRepository) List(ctx context.Context) ([]models.Tag, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	cursor, err := db.Database.Collection("tags").Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	tags := []models.Tag{}
+	if err := cursor.All(ctx, &tags); err != nil {
+		return nil, err
+	}
+
+	counts, err := db.Database.Collection("words").Aggregate(ctx, []bson.M{
+		{"$match": liveWords(bson.M{"tags": bson.M{"$exists": true}})},
+		{"$unwind": "$tags"},
+		{"$group": bson.M{"_id": "$tags", "count": bson.M{"$sum": 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer counts.Close(ctx)
+
+	var rows []struct {
+		Name  string `bson:"_id"`
+		Count int64  `bson:"count"`
+	}
+	if err := counts.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	byName := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		byName[row.Name] = row.Count
+	}
+	for i := range tags {
+		tags[i].WordCount = byName[tags[i].Name]
+	}
+	return tags, nil
+}
+
+// MissingNames returns the names that have no tag, in the order given.
+func (r *TagRepository) MissingNames(ctx context.Context, names []string) ([]string, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	found, err := db.Database.Collection("tags").Distinct(ctx, "name", bson.M{"name": bson.M{"$in": names}})
+	if err != nil {
+		return nil, err
+	}
+	exists := make(map[string]bool, len(found))
+	for _, name := range found {
+		if s, ok := name.(string); ok {
+			exists[s] = true
+		}
+	}
+	var missing []string
+	for _, name := range names {
+		if !exists[name] {
+			missing = append(missing, name)
+		}
+	}
+	return missing, nil
+}
+
+// Update saves the name and description. Returns false if the tag does not exist.
+func (r *TagRepository) Update(ctx context.Context, tag *models.Tag) (bool, error) {
+	tag.UpdatedAt = time.Now()
+	res, err := db.Database.Collection("tags").UpdateOne(ctx,
+		bson.M{"_id": tag.ID},
+		bson.M{"$set": bson.M{
+			"name":        tag.Name,
+			"description": tag.Description,
+			"updatedAt":   tag.UpdatedAt,
+		}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+// Delete removes a tag. Returns false if it does not exist.
+func (r *TagRepository) Delete(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	res, err := db.Database.Collection("tags").DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return false, err
+	}
+	return res.DeletedCount > 0, nil
+}
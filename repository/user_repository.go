@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"USDT_BackEnd/db"
 	"USDT_BackEnd/models"
@@ -58,7 +59,7 @@ func (r *UserRepository) GetSubscribedUsers(ctx context.Context) ([]models.User,
 
 func (r *UserRepository) GetFavorites(ctx context.Context, favoriteIDs []primitive.ObjectID) ([]models.Word, error) {
 	var words []models.Word
-	cursor, err := db.Database.Collection("words").Find(ctx, bson.M{"_id": bson.M{"$in": favoriteIDs}})
+	cursor, err := db.Database.Collection("words").Find(ctx, liveWords(bson.M{"_id": bson.M{"$in": favoriteIDs}}))
 	if err != nil {
 		return nil, err
 	}
@@ -89,6 +90,16 @@ func (r *UserRepository) RemoveFavorite(ctx context.Context, userID, wordID prim
 	return err
 }
 
+// RemoveWordsFromFavorites drops purged words from every user's favorites.
+func (r *UserRepository) RemoveWordsFromFavorites(ctx context.Context, wordIDs []primitive.ObjectID) error {
+	_, err := db.Database.Collection("users").UpdateMany(
+		ctx,
+		bson.M{"favorites": bson.M{"$in": wordIDs}},
+		bson.M{"$pull": bson.M{"favorites": bson.M{"$in": wordIDs}}},
+	)
+	return err
+}
+
 // GetFavoritesPaginated returns favorites with pagination
 func (r *UserRepository) GetFavoritesPaginated(ctx context.Context, userID primitive.ObjectID, page, limit int) ([]models.Word, bool, error) {
 	collection := db.Database.Collection("users")
@@ -113,7 +124,7 @@ func (r *UserRepository) GetFavoritesPaginated(ctx context.Context, userID primi
 
 	// Fetch words from "words" collection
 	var words []models.Word
-	cursor, err := db.Database.Collection("words").Find(ctx, bson.M{"_id": bson.M{"$in": pagedFavorites}})
+	cursor, err := db.Database.Collection("words").Find(ctx, liveWords(bson.M{"_id": bson.M{"$in": pagedFavorites}}))
 	if err != nil {
 		return nil, false, err
 	}
@@ -126,6 +137,7 @@ func (r *UserRepository) GetFavoritesPaginated(ctx context.Context, userID primi
 	hasMore := end < len(user.Favorites)
 	return words, hasMore, nil
 }
+
 // DecrementSearchesLeft atomically decrements searchesLeft by 1, only if > 0.
 // Returns an error if no document was matched (i.e. searchesLeft was already 0).
 func (r *UserRepository) DecrementSearchesLeft(ctx context.Context, userID primitive.ObjectID) error {
@@ -143,14 +155,61 @@ func (r *UserRepository) DecrementSearchesLeft(ctx context.Context, userID primi
 	return nil
 }
 
-func (r *UserRepository) DeleteUserByID(ctx context.Context, userID primitive.ObjectID) error {
-	_, err := db.Database.Collection("users").DeleteOne(
+// ScheduleDeletion marks the account for deletion once at has passed.
+func (r *UserRepository) ScheduleDeletion(ctx context.Context, userID primitive.ObjectID, at time.Time) error {
+	_, err := db.Database.Collection("users").UpdateOne(
 		ctx,
 		bson.M{"_id": userID},
+		bson.M{"$set": bson.M{"deleteAfter": at, "updatedAt": time.Now()}},
 	)
 	return err
 }
 
+// CancelDeletion clears a scheduled deletion. It returns false if none was scheduled.
+func (r *UserRepository) CancelDeletion(ctx context.Context, userID primitive.ObjectID) (bool, error) {
+	res, err := db.Database.Collection("users").UpdateOne(
+		ctx,
+		bson.M{"_id": userID, "deleteAfter": bson.M{"$ne": nil}},
+		bson.M{
+			"$unset": bson.M{"deleteAfter": ""},
+			"$set":   bson.M{"updatedAt": time.Now()},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+// GetUsersDueForDeletion returns up to limit accounts whose grace period ended before now.
+func (r *UserRepository) GetUsersDueForDeletion(ctx context.Context, now time.Time, limit int) ([]models.User, error) {
+	opts := options.Find().SetLimit(int64(limit))
+	cursor, err := db.Database.Collection("users").Find(ctx, bson.M{"deleteAfter": bson.M{"$lte": now}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// DeleteScheduledUser removes the user document if its deletion is still due.
+// It returns false when the user cancelled in the meantime.
+func (r *UserRepository) DeleteScheduledUser(ctx context.Context, userID primitive.ObjectID, now time.Time) (bool, error) {
+	res, err := db.Database.Collection("users").DeleteOne(
+		ctx,
+		bson.M{"_id": userID, "deleteAfter": bson.M{"$lte": now}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.DeletedCount > 0, nil
+}
+
 // GetAllUsers finds users whose email matches the query (case-insensitive regex),
 // or by exact ObjectID if the query is a valid 24-char hex string.
 func (r *UserRepository) GetAllUsers(ctx context.Context, query string) ([]models.User, error) {
@@ -180,16 +239,78 @@ func (r *UserRepository) GetAllUsers(ctx context.Context, query string) ([]model
 	return users, nil
 }
 
-// LinkGoogle updates a user's email, authProvider, and googleId to link with Google.
-func (r *UserRepository) LinkGoogle(ctx context.Context, userID primitive.ObjectID, email, googleID string) error {
+// GetUserByIdentity finds the user that linked the given provider account.
+func (r *UserRepository) GetUserByIdentity(ctx context.Context, provider, subject string) (*models.User, error) {
+	var user models.User
+	err := db.Database.Collection("users").FindOne(ctx, bson.M{
+		"identities": bson.M{"$elemMatch": bson.M{"provider": provider, "subject": subject}},
+	}).Decode(&user)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// AddIdentity links a provider account. Returns false if the user already has that provider linked.
+func (r *UserRepository) AddIdentity(ctx context.Context, userID primitive.ObjectID, identity models.UserIdentity) (bool, error) {
+	res, err := db.Database.Collection("users").UpdateOne(
+		ctx,
+		bson.M{"_id": userID, "identities.provider": bson.M{"$ne": identity.Provider}},
+		bson.M{
+			"$push": bson.M{"identities": identity},
+			"$set":  bson.M{"updatedAt": time.Now()},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+// RemoveIdentity unlinks a provider, but only while the user keeps another way to sign in
+// (a password or a second identity). Returns false if nothing was removed.
+func (r *UserRepository) RemoveIdentity(ctx context.Context, userID primitive.ObjectID, provider string) (bool, error) {
+	res, err := db.Database.Collection("users").UpdateOne(
+		ctx,
+		bson.M{
+			"_id":                 userID,
+			"identities.provider": provider,
+			"$or": bson.A{
+				bson.M{"password": bson.M{"$nin": bson.A{"", nil}}},
+				bson.M{"identities.1": bson.M{"$exists": true}},
+			},
+		},
+		bson.M{
+			"$pull": bson.M{"identities": bson.M{"provider": provider}},
+			"$set":  bson.M{"updatedAt": time.Now()},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+// SetPasswordIfMissing adds a password to an account that signs in through providers only.
+// Returns false if the account already has one.
+func (r *UserRepository) SetPasswordIfMissing(ctx context.Context, userID primitive.ObjectID, hashedPassword string) (bool, error) {
+	res, err := db.Database.Collection("users").UpdateOne(
+		ctx,
+		bson.M{"_id": userID, "password": bson.M{"$in": bson.A{"", nil}}},
+		bson.M{"$set": bson.M{"password": hashedPassword, "updatedAt": time.Now()}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+// UpdateSearchesLeft sets subscription.searchesLeft to the given count.
+func (r *UserRepository) UpdateSearchesLeft(ctx context.Context, userID primitive.ObjectID, count int) error {
 	res, err := db.Database.Collection("users").UpdateOne(
 		ctx,
 		bson.M{"_id": userID},
-		bson.M{"$set": bson.M{
-			"email":        email,
-			"authProvider": "GOOGLE",
-			"googleId":     googleID,
-		}},
+		bson.M{"$set": bson.M{"subscription.searchesLeft": count}},
 	)
 	if err != nil {
 		return err
@@ -200,12 +321,41 @@ func (r *UserRepository) LinkGoogle(ctx context.Context, userID primitive.Object
 	return nil
 }
 
-// UpdateSearchesLeft sets subscription.searchesLeft to the given count.
-func (r *UserRepository) UpdateSearchesLeft(ctx context.Context, userID primitive.ObjectID, count int) error {
+// SetSearchDictionaries saves the dictionaries searched by default; empty means all.
+func (r *UserRepository) SetSearchDictionaries(ctx context.Context, userID primitive.ObjectID, ids []primitive.ObjectID) error {
+	update := bson.M{"$set": bson.M{"searchDictionaries": ids, "updatedAt": time.Now()}}
+	if len(ids) == 0 {
+		update = bson.M{"$unset": bson.M{"searchDictionaries": ""}, "$set": bson.M{"updatedAt": time.Now()}}
+	}
+	res, err := db.Database.Collection("users").UpdateOne(ctx, bson.M{"_id": userID}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return errors.New("user not found")
+	}
+	return nil
+}
+
+// RemoveSearchDictionary drops a deleted dictionary from every user's search default.
+func (r *UserRepository) RemoveSearchDictionary(ctx context.Context, dictionaryID primitive.ObjectID) error {
+	_, err := db.Database.Collection("users").UpdateMany(
+		ctx,
+		bson.M{"searchDictionaries": dictionaryID},
+		bson.M{"$pull": bson.M{"searchDictionaries": dictionaryID}},
+	)
+	return err
+}
+
+// IncrementTokenVersion bumps tokenVersion so every previously issued access token is rejected.
+func (r *UserRepository) IncrementTokenVersion(ctx context.Context, userID primitive.ObjectID) error {
 	res, err := db.Database.Collection("users").UpdateOne(
 		ctx,
 		bson.M{"_id": userID},
-		bson.M{"$set": bson.M{"subscription.searchesLeft": count}},
+		bson.M{
+			"$inc": bson.M{"tokenVersion": 1},
+			"$set": bson.M{"updatedAt": time.Now()},
+		},
 	)
 	if err != nil {
 		return err
@@ -215,3 +365,96 @@ func (r *UserRepository) UpdateSearchesLeft(ctx context.Context, userID primitiv
 	}
 	return nil
 }
+
+// SetEmailVerified marks the account with the given email as verified.
+func (r *UserRepository) SetEmailVerified(ctx context.Context, email string) error {
+	res, err := db.Database.Collection("users").UpdateOne(
+		ctx,
+		bson.M{"email": email},
+		bson.M{"$set": bson.M{"emailVerified": true, "updatedAt": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return errors.New("user not found")
+	}
+	return nil
+}
+
+// SetPendingTwoFactorSecret stores a TOTP secret that is waiting for its first confirmed code.
+func (r *UserRepository) SetPendingTwoFactorSecret(ctx context.Context, userID primitive.ObjectID, sealedSecret string) error {
+	_, err := db.Database.Collection("users").UpdateOne(
+		ctx,
+		bson.M{"_id": userID},
+		bson.M{"$set": bson.M{"twoFactor.pendingSecret": sealedSecret, "updatedAt": time.Now()}},
+	)
+	return err
+}
+
+// EnableTwoFactor activates TOTP with the given secret and recovery code hashes.
+func (r *UserRepository) EnableTwoFactor(ctx context.Context, userID primitive.ObjectID, sealedSecret string, recoveryHashes []string, step int64) error {
+	now := time.Now()
+	_, err := db.Database.Collection("users").UpdateOne(
+		ctx,
+		bson.M{"_id": userID},
+		bson.M{"$set": bson.M{
+			"twoFactor": models.TwoFactorSettings{
+				Enabled:       true,
+				Secret:        sealedSecret,
+				RecoveryCodes: recoveryHashes,
+				LastUsedStep:  step,
+				EnabledAt:     &now,
+			},
+			"updatedAt": now,
+		}},
+	)
+	return err
+}
+
+func (r *UserRepository) DisableTwoFactor(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := db.Database.Collection("users").UpdateOne(
+		ctx,
+		bson.M{"_id": userID},
+		bson.M{"$set": bson.M{
+			"twoFactor": models.TwoFactorSettings{},
+			"updatedAt": time.Now(),
+		}},
+	)
+	return err
+}
+
+func (r *UserRepository) SetRecoveryCodes(ctx context.Context, userID primitive.ObjectID, recoveryHashes []string) error {
+	_, err := db.Database.Collection("users").UpdateOne(
+		ctx,
+		bson.M{"_id": userID},
+		bson.M{"$set": bson.M{"twoFactor.recoveryCodes": recoveryHashes, "updatedAt": time.Now()}},
+	)
+	return err
+}
+
+// ClaimTOTPStep records step as used. Returns false if it (or a later step) was already used.
+func (r *UserRepository) ClaimTOTPStep(ctx context.Context, userID primitive.ObjectID, step int64) (bool, error) {
+	res, err := db.Database.Collection("users").UpdateOne(
+		ctx,
+		bson.M{"_id": userID, "twoFactor.lastUsedStep": bson.M{"$not": bson.M{"$gte": step}}},
+		bson.M{"$set": bson.M{"twoFactor.lastUsedStep": step}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+// ConsumeRecoveryCode removes a recovery code hash. Returns false if it was not present.
+func (r *UserRepository) ConsumeRecoveryCode(ctx context.Context, userID primitive.ObjectID, codeHash string) (bool, error) {
+	res, err := db.Database.Collection("users").UpdateOne(
+		ctx,
+		bson.M{"_id": userID, "twoFactor.recoveryCodes": codeHash},
+		bson.M{"$pull": bson.M{"twoFactor.recoveryCodes": codeHash}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
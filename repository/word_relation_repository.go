@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+
+	"USDT_BackEnd/db"
+	"USDT_BackEnd/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type WordRelationRepository struct{}
+
+// Create fails with a duplicate key error if the two words are already related.
+func (r *WordRelationRepository) Create(ctx context.Context, rel *models.WordRelation) error {
+	res, err := db.Database.Collection("word_relations").InsertOne(ctx, rel)
+	if err != nil {
+		return err
+	}
+	if id, ok := res.InsertedID.(primitive.ObjectID); ok {
+		rel.ID = id
+	}
+	return nil
+}
+
+// Get returns the relation between two words, whichever side it is stored from.
+func (r *WordRelationRepository) Get(ctx context.Context, a, b primitive.ObjectID) (*models.WordRelation, error) {
+	var rel models.WordRelation
+	err := db.Database.Collection("word_relations").FindOne(ctx, pairFilter(a, b)).Decode(&rel)
+	if err != nil {
+		return nil, err
+	}
+	return &rel, nil
+}
+
+// ListForWord returns every relation of a word, oldest first.
+func (r *WordRelationRepository) ListForWord(ctx context.Context, id primitive.ObjectID) ([]models.WordRelation, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}})
+	cursor, err := db.Database.Collection("word_relations").Find(ctx,
+		bson.M{"$or": []bson.M{{"from": id}, {"to": id}}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	relations := []models.WordRelation{}
+	if err := cursor.All(ctx, &relations); err != nil {
+		return nil, err
+	}
+	return relations, nil
+}
+
+// Delete removes the relation between two words. Returns false if there was none.
+func (r *WordRelationRepository) Delete(ctx context.Context, a, b primitive.ObjectID) (bool, error) {
+	res, err := db.Database.Collection("word_relations").DeleteOne(ctx, pairFilter(a, b))
+	if err != nil {
+		return false, err
+	}
+	return res.DeletedCount > 0, nil
+}
+
+// DeleteForWords removes every relation that has one of the words on either side.
+func (r *WordRelationRepository) DeleteForWords(ctx context.Context, ids []primitive.ObjectID) error {
+	_, err := db.Database.Collection("word_relations").DeleteMany(ctx, bson.M{"$or": []bson.M{
+		{"from": bson.M{"$in": ids}},
+		{"to": bson.M{"$in": ids}},
+	}})
+	return err
+}
+
+func pairFilter(a, b primitive.ObjectID) bson.M {
+	return bson.M{"$or": []bson.M{
+		{"from": a, "to": b},
+		{"from": b, "to": a},
+	}}
+}
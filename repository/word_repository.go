@@ -9,6 +9,7 @@ import (
 
 	"USDT_BackEnd/db"
 	"USDT_BackEnd/models"
+	"USDT_BackEnd/utils"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -17,11 +18,45 @@ import (
 
 type WordRepository struct{}
 
-func (r *WordRepository) SearchWords(ctx context.Context, query string, kanaQueries []string) ([]models.Word, error) {
+// liveWords narrows a words filter to documents that are not in the trash.
+func liveWords(filter bson.M) bson.M {
+	filter["deletedAt"] = nil
+	return filter
+}
+
+// WordFilter narrows word listings to a tag and/or JLPT level, within some
+// dictionaries. Empty fields match everything.
+type WordFilter struct {
+	Tag          string
+	JLPTLevel    string
+	Dictionaries []primitive.ObjectID
+}
+
+// IsEmpty reports whether the filter picks a category. The dictionaries only set
+// the scope, so a search limited to them still needs a query.
+func (f WordFilter) IsEmpty() bool {
+	return f.Tag == "" && f.JLPTLevel == ""
+}
+
+// apply adds the filter's conditions to a words filter.
+func (f WordFilter) apply(filter bson.M) bson.M {
+	if f.Tag != "" {
+		filter["tags"] = f.Tag
+	}
+	if f.JLPTLevel != "" {
+		filter["jlptLevel"] = f.JLPTLevel
+	}
+	if len(f.Dictionaries) > 0 {
+		filter["dictionaryId"] = bson.M{"$in": f.Dictionaries}
+	}
+	return filter
+}
+
+func (r *WordRepository) SearchWords(ctx context.Context, query string, kanaQueries []string, f WordFilter) ([]models.Word, error) {
 	collection := db.Database.Collection("words")
 
 	q := strings.TrimSpace(query)
-	if q == "" {
+	if q == "" && f.IsEmpty() {
 		return nil, nil
 	}
 
@@ -35,6 +70,7 @@ func (r *WordRepository) SearchWords(ctx context.Context, query string, kanaQuer
 		{"japanese": bson.M{"$regex": escaped, "$options": "i"}},
 		{"myanmar": bson.M{"$regex": escaped, "$options": "i"}},
 		{"subTerm": bson.M{"$regex": escaped, "$options": "i"}},
+		{"readings.kana": bson.M{"$regex": escaped, "$options": "i"}},
 	}
 
 	// Add hiragana and katakana variants to Japanese field searches.
@@ -44,11 +80,16 @@ func (r *WordRepository) SearchWords(ctx context.Context, query string, kanaQuer
 			orClauses = append(orClauses,
 				bson.M{"japanese": bson.M{"$regex": esc, "$options": "i"}},
 				bson.M{"subTerm": bson.M{"$regex": esc, "$options": "i"}},
+				bson.M{"readings.kana": bson.M{"$regex": esc, "$options": "i"}},
 			)
 		}
 	}
 
-	filter := bson.M{"$or": orClauses}
+	filter := bson.M{}
+	if q != "" {
+		filter["$or"] = orClauses
+	}
+	filter = liveWords(f.apply(filter))
 
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
@@ -71,12 +112,46 @@ func (r *WordRepository) SearchWords(ctx context.Context, query string, kanaQuer
 func (r *WordRepository) GetWordByID(ctx context.Context, id primitive.ObjectID) (*models.Word, error) {
 	collection := db.Database.Collection("words")
 	var word models.Word
-	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&word)
+	err := collection.FindOne(ctx, liveWords(bson.M{"_id": id})).Decode(&word)
 	if err != nil {
 		return nil, err
 	}
 	return &word, nil
 }
+
+// GetLiveWordsByIDs returns the words with the given IDs that are not in the trash.
+func (r *WordRepository) GetLiveWordsByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.Word, error) {
+	cursor, err := db.Database.Collection("words").Find(ctx, liveWords(bson.M{"_id": bson.M{"$in": ids}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	words := []models.Word{}
+	if err := cursor.All(ctx, &words); err != nil {
+		return nil, err
+	}
+	return words, nil
+}
+
+// FindLiveByJapanese returns the IDs, Japanese and SubTerm of live words whose
+// Japanese is one of the given texts.
+func (r *WordRepository) FindLiveByJapanese(ctx context.Context, texts []string) ([]models.Word, error) {
+	opts := options.Find().SetProjection(bson.M{"japanese": 1, "subTerm": 1})
+	cursor, err := db.Database.Collection("words").Find(ctx, liveWords(bson.M{"japanese": bson.M{"$in": texts}}), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	words := []models.Word{}
+	if err := cursor.All(ctx, &words); err != nil {
+		return nil, err
+	}
+	return words, nil
+}
+
+// BulkInsert assigns each word an ID and inserts them in batches.
 func (r *WordRepository) BulkInsert(ctx context.Context, words []models.Word) (int, error) {
 	collection := db.Database.Collection("words")
 
@@ -96,10 +171,13 @@ func (r *WordRepository) BulkInsert(ctx context.Context, words []models.Word) (i
 
 		batch := words[i:end]
 		docs := make([]interface{}, len(batch))
-		for j, w := range batch {
-			w.CreatedAt = now
-			w.UpdatedAt = now
-			docs[j] = w
+		for j := range batch {
+			if batch[j].ID.IsZero() {
+				batch[j].ID = primitive.NewObjectID()
+			}
+			batch[j].CreatedAt = now
+			batch[j].UpdatedAt = now
+			docs[j] = batch[j]
 		}
 
 		_, err := collection.InsertMany(ctx, docs)
@@ -112,8 +190,60 @@ func (r *WordRepository) BulkInsert(ctx context.Context, words []models.Word) (i
 	return totalInserted, nil
 }
 
-func (r *WordRepository) GetAllWords(ctx context.Context, page, limit int, query string, kanaQueries []string) ([]models.Word, bool, int64, error) {
+func (r *WordRepository) GetAllWords(ctx context.Context, page, limit int, query string, kanaQueries []string, f WordFilter) ([]models.Word, bool, int64, error) {
 	collection := db.Database.Collection("words")
+	filter := allWordsFilter(query, kanaQueries, f)
+
+	opts := options.Find().
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "createdAt", Value: -1}}) // Descending order
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, false, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var words []models.Word
+	if err := cursor.All(ctx, &words); err != nil {
+		return nil, false, 0, err
+	}
+
+	totalCount, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, false, 0, err
+	}
+
+	hasMore := int64(page*limit) < totalCount
+
+	return words, hasMore, totalCount, nil
+}
+
+// EachWord calls fn for every live word matching the admin listing filters, oldest
+// first, without loading them all at once. It stops at the first error.
+func (r *WordRepository) EachWord(ctx context.Context, query string, kanaQueries []string, f WordFilter, fn func(*models.Word) error) error {
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}})
+	cursor, err := db.Database.Collection("words").Find(ctx, allWordsFilter(query, kanaQueries, f), opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var word models.Word
+		if err := cursor.Decode(&word); err != nil {
+			return err
+		}
+		if err := fn(&word); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// allWordsFilter builds the filter behind the admin word listing and export.
+func allWordsFilter(query string, kanaQueries []string, f WordFilter) bson.M {
 	filter := bson.M{}
 	if query != "" {
 		// When the query was romaji, use regex so hiragana/katakana variants are
@@ -125,6 +255,7 @@ func (r *WordRepository) GetAllWords(ctx context.Context, page, limit int, query
 				{"japanese": bson.M{"$regex": escapedQ, "$options": "i"}},
 				{"myanmar": bson.M{"$regex": escapedQ, "$options": "i"}},
 				{"subTerm": bson.M{"$regex": escapedQ, "$options": "i"}},
+				{"readings.kana": bson.M{"$regex": escapedQ, "$options": "i"}},
 			}
 			for _, kana := range kanaQueries {
 				if kana != "" && kana != query {
@@ -132,27 +263,222 @@ func (r *WordRepository) GetAllWords(ctx context.Context, page, limit int, query
 					orClauses = append(orClauses,
 						bson.M{"japanese": bson.M{"$regex": esc, "$options": "i"}},
 						bson.M{"subTerm": bson.M{"$regex": esc, "$options": "i"}},
+						bson.M{"readings.kana": bson.M{"$regex": esc, "$options": "i"}},
 					)
 				}
 			}
 			filter = bson.M{"$or": orClauses}
 		} else {
-			filter = bson.M{"$text": bson.M{"$search": query}}
+			// Alternative readings are not in the text index; match them exactly
+			// (through the readings.kana index, which $text inside $or requires).
+			filter = bson.M{"$or": []bson.M{
+				{"$text": bson.M{"$search": query}},
+				{"readings.kana": bson.M{"$in": []string{query, utils.KatakanaToHiragana(query)}}},
+			}}
 		}
 	}
 
+	return liveWords(f.apply(filter))
+}
+
+func (r *WordRepository) CreateWord(ctx context.Context, word *models.Word) error {
+	word.CreatedAt = time.Now()
+	word.UpdatedAt = time.Now()
+	res, err := db.Database.Collection("words").InsertOne(ctx, word)
+	if err != nil {
+		return err
+	}
+	if id, ok := res.InsertedID.(primitive.ObjectID); ok {
+		word.ID = id
+	}
+	return nil
+}
+
+// UpdateWord saves the editable fields and returns the stored word. createdAt and
+// ignore are left as they are.
+func (r *WordRepository) UpdateWord(ctx context.Context, id primitive.ObjectID, word *models.Word) (*models.Word, error) {
+	return r.setWordFields(ctx, id, bson.M{
+		"subTerm":      word.SubTerm,
+		"japanese":     word.Japanese,
+		"readings":     word.Readings,
+		"furigana":     word.Furigana,
+		"pitchAccent":  word.PitchAccent,
+		"senses":       word.Senses,
+		"myanmar":      word.Myanmar,
+		"english":      word.English,
+		"tags":         word.Tags,
+		"jlptLevel":    word.JLPTLevel,
+		"imageUrl":     word.ImageURL,
+		"dictionaryId": word.DictionaryID,
+	})
+}
+
+// RestoreSnapshot puts a word back to the state stored in a revision. A snapshot
+// without a dictionary leaves the word in the one it is in.
+func (r *WordRepository) RestoreSnapshot(ctx context.Context, id primitive.ObjectID, snap models.WordSnapshot) (*models.Word, error) {
+	fields := bson.M{
+		"subTerm":     snap.SubTerm,
+		"japanese":    snap.Japanese,
+		"readings":    snap.Readings,
+		"furigana":    snap.Furigana,
+		"pitchAccent": snap.PitchAccent,
+		"senses":      snap.Senses,
+		"myanmar":     snap.Myanmar,
+		"english":     snap.English,
+		"examples":    snap.Examples,
+		"tags":        snap.Tags,
+		"jlptLevel":   snap.JLPTLevel,
+		"imageUrl":    snap.ImageURL,
+		"ignore":      snap.Ignore,
+	}
+	if !snap.DictionaryID.IsZero() {
+		fields["dictionaryId"] = snap.DictionaryID
+	}
+	return r.setWordFields(ctx, id, fields)
+}
+
+// setWordFields sets fields and updatedAt, unsetting an empty imageUrl, and returns the updated word.
+func (r *WordRepository) setWordFields(ctx context.Context, id primitive.ObjectID, fields bson.M) (*models.Word, error) {
+	update := bson.M{}
+	if url, ok := fields["imageUrl"]; ok && url == "" {
+		delete(fields, "imageUrl")
+		update["$unset"] = bson.M{"imageUrl": ""}
+	}
+	update["$set"] = fields
+	return r.updateWord(ctx, bson.M{"_id": id}, update)
+}
+
+// RenameTag replaces a tag name on every word that has it, including words in the trash.
+func (r *WordRepository) RenameTag(ctx context.Context, from, to string) (int64, error) {
+	res, err := db.Database.Collection("words").UpdateMany(ctx,
+		bson.M{"tags": from},
+		bson.M{"$set": bson.M{"tags.$": to}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// RemoveTag takes a tag name off every word that has it, including words in the trash.
+func (r *WordRepository) RemoveTag(ctx context.Context, name string) (int64, error) {
+	res, err := db.Database.Collection("words").UpdateMany(ctx,
+		bson.M{"tags": name},
+		bson.M{"$pull": bson.M{"tags": name}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// CountInDictionary counts the words in a dictionary, including those in the trash.
+func (r *WordRepository) CountInDictionary(ctx context.Context, dictionaryID primitive.ObjectID) (int64, error) {
+	return db.Database.Collection("words").CountDocuments(ctx, bson.M{"dictionaryId": dictionaryID})
+}
+
+// AddAudio attaches an audio clip unless the word already has maxClips, and
+// returns the updated word. mongo.ErrNoDocuments means no live word had room.
+func (r *WordRepository) AddAudio(ctx context.Context, wordID primitive.ObjectID, clip models.WordAudio, maxClips int) (*models.Word, error) {
+	return r.updateWord(ctx, bson.M{
+		"_id":                               wordID,
+		fmt.Sprintf("audio.%d", maxClips-1): bson.M{"$exists": false},
+	}, bson.M{
+		"$push": bson.M{"audio": clip},
+	})
+}
+
+// DeleteAudio detaches an audio clip and returns the updated word.
+func (r *WordRepository) DeleteAudio(ctx context.Context, wordID, clipID primitive.ObjectID) (*models.Word, error) {
+	return r.updateWord(ctx, bson.M{"_id": wordID, "audio._id": clipID}, bson.M{
+		"$pull": bson.M{"audio": bson.M{"_id": clipID}},
+	})
+}
+
+// AddExample appends an example sentence and returns the updated word.
+func (r *WordRepository) AddExample(ctx context.Context, wordID primitive.ObjectID, example models.WordExample) (*models.Word, error) {
+	return r.updateWord(ctx, bson.M{"_id": wordID}, bson.M{
+		"$push": bson.M{"examples": example},
+	})
+}
+
+// UpdateExample replaces the text of one example and returns the updated word.
+func (r *WordRepository) UpdateExample(ctx context.Context, wordID primitive.ObjectID, example models.WordExample) (*models.Word, error) {
+	return r.updateWord(ctx, bson.M{"_id": wordID, "examples._id": example.ID}, bson.M{
+		"$set": bson.M{"examples.$": example},
+	})
+}
+
+// DeleteExample removes one example and returns the updated word.
+func (r *WordRepository) DeleteExample(ctx context.Context, wordID, exampleID primitive.ObjectID) (*models.Word, error) {
+	return r.updateWord(ctx, bson.M{"_id": wordID, "examples._id": exampleID}, bson.M{
+		"$pull": bson.M{"examples": bson.M{"_id": exampleID}},
+	})
+}
+
+// updateWord applies update to the live word matching filter, bumping updatedAt,
+// and returns the updated word. mongo.ErrNoDocuments means nothing matched.
+func (r *WordRepository) updateWord(ctx context.Context, filter, update bson.M) (*models.Word, error) {
+	set, _ := update["$set"].(bson.M)
+	if set == nil {
+		set = bson.M{}
+		update["$set"] = set
+	}
+	set["updatedAt"] = time.Now()
+
+	var word models.Word
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := db.Database.Collection("words").FindOneAndUpdate(ctx, liveWords(filter), update, opts).Decode(&word)
+	if err != nil {
+		return nil, err
+	}
+	return &word, nil
+}
+
+// DeleteWord moves a word to the trash. It returns false if there was no such word,
+// or it was already in the trash.
+func (r *WordRepository) DeleteWord(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	res, err := db.Database.Collection("words").UpdateOne(ctx,
+		liveWords(bson.M{"_id": id}),
+		bson.M{"$set": bson.M{"deletedAt": time.Now()}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+// RestoreWord takes a word out of the trash. It returns false if the word was not in the trash.
+func (r *WordRepository) RestoreWord(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	res, err := db.Database.Collection("words").UpdateOne(ctx,
+		bson.M{"_id": id, "deletedAt": bson.M{"$ne": nil}},
+		bson.M{
+			"$unset": bson.M{"deletedAt": ""},
+			"$set":   bson.M{"updatedAt": time.Now()},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+// GetTrash returns trashed words, most recently deleted first.
+func (r *WordRepository) GetTrash(ctx context.Context, page, limit int) ([]models.Word, bool, int64, error) {
+	collection := db.Database.Collection("words")
+	filter := bson.M{"deletedAt": bson.M{"$ne": nil}}
+
 	opts := options.Find().
 		SetSkip(int64((page - 1) * limit)).
 		SetLimit(int64(limit)).
-		SetSort(bson.D{{Key: "createdAt", Value: -1}}) // Descending order
-
+		SetSort(bson.D{{Key: "deletedAt", Value: -1}})
 	cursor, err := collection.Find(ctx, filter, opts)
 	if err != nil {
 		return nil, false, 0, err
 	}
 	defer cursor.Close(ctx)
 
-	var words []models.Word
+	words := []models.Word{}
 	if err := cursor.All(ctx, &words); err != nil {
 		return nil, false, 0, err
 	}
@@ -161,47 +487,46 @@ func (r *WordRepository) GetAllWords(ctx context.Context, page, limit int, query
 	if err != nil {
 		return nil, false, 0, err
 	}
-
-	hasMore := int64(page*limit) < totalCount
-
-	return words, hasMore, totalCount, nil
+	return words, int64(page*limit) < totalCount, totalCount, nil
 }
 
-func (r *WordRepository) CreateWord(ctx context.Context, word *models.Word) error {
-	word.CreatedAt = time.Now()
-	word.UpdatedAt = time.Now()
-	_, err := db.Database.Collection("words").InsertOne(ctx, word)
-	return err
-}
+// GetTrashedBefore returns up to limit words that were trashed before cutoff.
+func (r *WordRepository) GetTrashedBefore(ctx context.Context, cutoff time.Time, limit int) ([]models.Word, error) {
+	opts := options.Find().SetLimit(int64(limit)).SetSort(bson.D{{Key: "deletedAt", Value: 1}})
+	cursor, err := db.Database.Collection("words").Find(ctx, bson.M{"deletedAt": bson.M{"$lt": cutoff}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
 
-func (r *WordRepository) UpdateWord(ctx context.Context, id primitive.ObjectID, word *models.Word) error {
-	word.UpdatedAt = time.Now()
-	_, err := db.Database.Collection("words").UpdateOne(ctx,
-		bson.M{"_id": id},
-		bson.M{"$set": word})
-	return err
+	var words []models.Word
+	if err := cursor.All(ctx, &words); err != nil {
+		return nil, err
+	}
+	return words, nil
 }
 
-func (r *WordRepository) DeleteWord(ctx context.Context, id primitive.ObjectID) error {
-	_, err := db.Database.Collection("words").DeleteOne(ctx, bson.M{"_id": id})
-	return err
+// PurgeWord permanently removes a trashed word. Words that were restored
+// in the meantime are left alone; it returns false for them.
+func (r *WordRepository) PurgeWord(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	res, err := db.Database.Collection("words").DeleteOne(ctx, bson.M{"_id": id, "deletedAt": bson.M{"$ne": nil}})
+	if err != nil {
+		return false, err
+	}
+	return res.DeletedCount > 0, nil
 }
 
-func (r *WordRepository) SetWordIgnore(ctx context.Context, id primitive.ObjectID, ignore bool) error {
-	_, err := db.Database.Collection("words").UpdateOne(ctx,
-		bson.M{"_id": id},
-		bson.M{"$set": bson.M{"ignore": ignore, "updatedAt": time.Now()}},
-	)
-	return err
+func (r *WordRepository) SetWordIgnore(ctx context.Context, id primitive.ObjectID, ignore bool) (*models.Word, error) {
+	return r.setWordFields(ctx, id, bson.M{"ignore": ignore})
 }
 
-// GetDuplicateWords finds words that share the same japanese+subTerm, with pagination and optional search.
-// Words with ignore=true are excluded from duplicate detection.
+// GetDuplicateWords finds words in the same dictionary that share the same japanese+subTerm, with
+// pagination and optional search. Words with ignore=true are excluded from duplicate detection.
 func (r *WordRepository) GetDuplicateWords(ctx context.Context, page, limit int, query string) ([]bson.M, int64, error) {
 	collection := db.Database.Collection("words")
 
-	// Base match: exclude ignored words
-	matchStage := bson.M{"ignore": bson.M{"$ne": true}}
+	// Base match: exclude ignored and trashed words
+	matchStage := liveWords(bson.M{"ignore": bson.M{"$ne": true}})
 
 	// If search query provided, filter by japanese/english/myanmar/subTerm
 	if query != "" {
@@ -213,7 +538,7 @@ func (r *WordRepository) GetDuplicateWords(ctx context.Context, page, limit int,
 		}
 	}
 
-	// Aggregation: filter, normalize nulls, case-insensitive group by english+japanese+subTerm
+	// Aggregation: filter, normalize nulls, case-insensitive group by dictionary+english+japanese+subTerm
 	basePipeline := []bson.M{
 		{"$match": matchStage},
 		{"$addFields": bson.M{
@@ -224,21 +549,23 @@ func (r *WordRepository) GetDuplicateWords(ctx context.Context, page, limit int,
 		}},
 		{"$group": bson.M{
 			"_id": bson.M{
-				"english":  bson.M{"$toLower": "$english"},
-				"japanese": "$japanese",
-				"subTerm":  "$subTerm",
+				"dictionaryId": "$dictionaryId",
+				"english":      bson.M{"$toLower": "$english"},
+				"japanese":     "$japanese",
+				"subTerm":      "$subTerm",
 			},
 			"count":         bson.M{"$sum": 1},
 			"latestCreated": bson.M{"$max": "$createdAt"},
 			"words": bson.M{"$push": bson.M{
-				"_id":       "$_id",
-				"english":   "$english",
-				"japanese":  "$japanese",
-				"myanmar":   "$myanmar",
-				"subTerm":   "$subTerm",
-				"imageUrl":  "$imageUrl",
-				"ignore":    "$ignore",
-				"createdAt": "$createdAt",
+				"_id":          "$_id",
+				"english":      "$english",
+				"japanese":     "$japanese",
+				"myanmar":      "$myanmar",
+				"subTerm":      "$subTerm",
+				"imageUrl":     "$imageUrl",
+				"ignore":       "$ignore",
+				"createdAt":    "$createdAt",
+				"dictionaryId": "$dictionaryId",
 			}},
 		}},
 		{"$match": bson.M{"count": bson.M{"$gt": 1}}},
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+
+	"USDT_BackEnd/db"
+	"USDT_BackEnd/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type WordRevisionRepository struct{}
+
+// LatestRevision returns the newest revision of a word, or nil if it has none.
+func (r *WordRevisionRepository) LatestRevision(ctx context.Context, wordID primitive.ObjectID) (*models.WordRevision, error) {
+	var rev models.WordRevision
+	opts := options.FindOne().SetSort(bson.D{{Key: "revision", Value: -1}})
+	err := db.Database.Collection("word_revisions").FindOne(ctx, bson.M{"wordId": wordID}, opts).Decode(&rev)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
+
+// Insert fails with a duplicate key error when the revision number is already taken,
+// so two concurrent edits cannot both claim it.
+func (r *WordRevisionRepository) Insert(ctx context.Context, rev *models.WordRevision) error {
+	res, err := db.Database.Collection("word_revisions").InsertOne(ctx, rev)
+	if err != nil {
+		return err
+	}
+	if id, ok := res.InsertedID.(primitive.ObjectID); ok {
+		rev.ID = id
+	}
+	return nil
+}
+
+func (r *WordRevisionRepository) GetRevision(ctx context.Context, wordID primitive.ObjectID, revision int) (*models.WordRevision, error) {
+	var rev models.WordRevision
+	err := db.Database.Collection("word_revisions").FindOne(ctx, bson.M{"wordId": wordID, "revision": revision}).Decode(&rev)
+	if err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
+
+// ListRevisions returns revisions newest first. It fetches one extra revision past the
+// page so the oldest revision on the page can still be diffed against its predecessor.
+func (r *WordRevisionRepository) ListRevisions(ctx context.Context, wordID primitive.ObjectID, page, limit int) ([]models.WordRevision, int64, error) {
+	collection := db.Database.Collection("word_revisions")
+	filter := bson.M{"wordId": wordID}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "revision", Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit + 1))
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	revisions := []models.WordRevision{}
+	if err := cursor.All(ctx, &revisions); err != nil {
+		return nil, 0, err
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return revisions, total, nil
+}
+
+// ImageURLs lists every distinct image a word has ever had.
+func (r *WordRevisionRepository) ImageURLs(ctx context.Context, wordID primitive.ObjectID) ([]string, error) {
+	values, err := db.Database.Collection("word_revisions").Distinct(ctx, "snapshot.imageUrl", bson.M{"wordId": wordID})
+	if err != nil {
+		return nil, err
+	}
+	urls := make([]string, 0, len(values))
+	for _, v := range values {
+		if s, ok := v.(string); ok && s != "" {
+			urls = append(urls, s)
+		}
+	}
+	return urls, nil
+}
+
+func (r *WordRevisionRepository) DeleteByWord(ctx context.Context, wordID primitive.ObjectID) error {
+	_, err := db.Database.Collection("word_revisions").DeleteMany(ctx, bson.M{"wordId": wordID})
+	return err
+}
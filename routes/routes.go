@@ -7,34 +7,89 @@ import (
 	"USDT_BackEnd/config"
 	"USDT_BackEnd/handlers"
 	"USDT_BackEnd/middleware"
+	"USDT_BackEnd/models"
 	"USDT_BackEnd/services"
 
-	"github.com/dgrijalva/jwt-go"
+	"github.com/golang-jwt/jwt/v5"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 func RegisterRoutes(mux *http.ServeMux, cfg *config.Config) {
 	// ====== Services ======
-	userService := services.NewUserService(cfg)
+	keys := services.NewKeyManager(cfg)
+	keys.StartRotation()
+	userService := services.NewUserService(cfg, keys)
+	userService.StartAccountPurge()
+	userService.StartExportCleanup()
+	apiKeyService := services.NewAPIKeyService()
+	auditService := services.NewAuditService()
+	wordService := services.NewWordService()
+	wordService.StartTrashPurge(cfg.WordTrashRetentionDays)
+	tagService := services.NewTagService()
+	kanjiService := services.NewKanjiService()
+	dictionaryService := services.NewDictionaryService()
 
 	// ====== Handlers ======
-	wordHandler := handlers.NewWordHandler(userService)
-	userHandler := handlers.NewUserHandler(userService)
+	wordHandler := handlers.NewWordHandler(wordService, userService, kanjiService, auditService)
+	userHandler := handlers.NewUserHandler(userService, auditService)
+	jwksHandler := handlers.NewJWKSHandler(keys)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService, auditService)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	tagHandler := handlers.NewTagHandler(tagService, auditService)
+	kanjiHandler := handlers.NewKanjiHandler(kanjiService, auditService)
+	dictionaryHandler := handlers.NewDictionaryHandler(dictionaryService, auditService)
 
 	// ====== Middlewares ======
-	auth := middleware.AuthMiddleware(cfg)
+	auth := middleware.AuthMiddleware(cfg, keys, apiKeyService)
+	requireAdmin := middleware.RequireRole(models.RoleAdmin)
+	// admin routes only accept admin JWTs; adminScope routes also accept API keys with the scope.
+	admin := func(h http.HandlerFunc) http.Handler {
+		return auth(requireAdmin(middleware.RequireScope()(h)))
+	}
+	adminScope := func(scope string, h http.HandlerFunc) http.Handler {
+		return auth(requireAdmin(middleware.RequireScope(scope)(h)))
+	}
+	// readScope routes are for any signed-in user; API keys also need the scope.
+	readScope := func(scope string, h http.HandlerFunc) http.Handler {
+		return auth(middleware.RequireScope(scope)(h))
+	}
+	// userID hands the authenticated caller's user ID to h; chain it after auth.
+	userID := func(h func(http.ResponseWriter, *http.Request, primitive.ObjectID)) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			id, err := extractUserIDFromClaims(r.Context().Value(middleware.UserKey))
+			if err != nil {
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
+			h(w, r, id)
+		}
+	}
+	// withUser authenticates the request and hands the caller's user ID to h.
+	withUser := func(h func(http.ResponseWriter, *http.Request, primitive.ObjectID)) http.Handler {
+		return auth(userID(h))
+	}
 
 	// ========== PUBLIC ROUTES ==========
 
-	//Google OAuth
-	mux.HandleFunc("POST /api/auth/google/login", userHandler.GoogleLogin)
-	mux.HandleFunc("POST /api/auth/google/register", userHandler.GoogleRegister)
+	// Public keys for verifying our JWTs
+	mux.HandleFunc("GET /.well-known/jwks.json", jwksHandler.GetJWKS)
+
+	// External identity providers (google, apple, or the configured OIDC provider)
+	mux.HandleFunc("POST /api/auth/{provider}/login", userHandler.ProviderLogin)
+	mux.HandleFunc("POST /api/auth/{provider}/register", userHandler.ProviderRegister)
 	mux.HandleFunc("POST /api/auth/forgot-password", userHandler.ForgotPassword)
 	mux.HandleFunc("POST /api/auth/reset-password", userHandler.ResetPassword)
 
 	// User authentication
 	mux.HandleFunc("POST /api/auth/register", userHandler.Register)
 	mux.HandleFunc("POST /api/auth/login", userHandler.Login)
+	mux.HandleFunc("POST /api/auth/2fa/verify", userHandler.VerifyTwoFactorLogin)
+	mux.HandleFunc("POST /api/auth/verify-email", userHandler.VerifyEmail)
+	mux.HandleFunc("POST /api/auth/verify-email/resend", userHandler.ResendVerification)
+
+	// Sessions
+	mux.HandleFunc("POST /api/auth/refresh", userHandler.RefreshToken)
+	mux.HandleFunc("POST /api/auth/logout", userHandler.Logout)
 
 	// ========== AUTHENTICATED ROUTES ==========
 
@@ -67,6 +122,14 @@ func RegisterRoutes(mux *http.ServeMux, cfg *config.Config) {
 		wordHandler.GetWordByID(w, r, userID)
 	})))
 
+	// Dictionaries to pick from, and the ones each user searches by default
+	mux.Handle("GET /api/dictionaries", readScope(models.ScopeWordsRead, dictionaryHandler.ListDictionaries))
+	mux.Handle("GET /api/users/me/dictionaries", withUser(dictionaryHandler.GetSearchDictionaries))
+	mux.Handle("PUT /api/users/me/dictionaries", withUser(dictionaryHandler.SetSearchDictionaries))
+
+	// Pronunciation audio, for any signed-in user (does not use up searches)
+	mux.Handle("GET /api/words/{id}/audio/{audioId}", readScope(models.ScopeWordsRead, wordHandler.GetAudio))
+
 	mux.Handle("PUT /api/users/password", auth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		claims := r.Context().Value(middleware.UserKey)
 		if claims == nil {
@@ -129,6 +192,25 @@ func RegisterRoutes(mux *http.ServeMux, cfg *config.Config) {
 		userHandler.DeleteMe(w, r, userID)
 	})))
 
+	// Personal data export (poll until ready, then download)
+	mux.Handle("GET /api/users/me/export", withUser(userHandler.ExportMyData))
+
+	// Session management
+	mux.Handle("GET /api/users/me/sessions", withUser(userHandler.ListSessions))
+	mux.Handle("DELETE /api/users/me/sessions", withUser(userHandler.RevokeOtherSessions))
+	mux.Handle("DELETE /api/users/me/sessions/{id}", withUser(userHandler.RevokeSession))
+
+	// Linked sign-in providers
+	mux.Handle("POST /api/users/me/identities/{provider}", withUser(userHandler.LinkIdentity))
+	mux.Handle("DELETE /api/users/me/identities/{provider}", withUser(userHandler.UnlinkIdentity))
+	mux.Handle("POST /api/users/me/password", withUser(userHandler.AddPassword))
+
+	// Two-factor authentication
+	mux.Handle("POST /api/users/me/2fa/setup", withUser(userHandler.SetupTwoFactor))
+	mux.Handle("POST /api/users/me/2fa/enable", withUser(userHandler.EnableTwoFactor))
+	mux.Handle("POST /api/users/me/2fa/disable", withUser(userHandler.DisableTwoFactor))
+	mux.Handle("POST /api/users/me/2fa/recovery-codes", withUser(userHandler.RegenerateRecoveryCodes))
+
 	// Favorite management
 	mux.Handle("POST /api/users/favorites/add", auth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		claims := r.Context().Value(middleware.UserKey)
@@ -185,25 +267,85 @@ func RegisterRoutes(mux *http.ServeMux, cfg *config.Config) {
 	})))
 
 	// ========== ADMIN ROUTES ==========
-	mux.Handle("GET /api/words", auth(http.HandlerFunc(wordHandler.GetAllWords)))
-	mux.Handle("POST /api/words", auth(http.HandlerFunc(wordHandler.CreateWord)))
-	mux.Handle("PUT /api/words/{id}", auth(http.HandlerFunc(wordHandler.UpdateWord)))
-	mux.Handle("DELETE /api/words/{id}", auth(http.HandlerFunc(wordHandler.DeleteWord)))
+	mux.Handle("GET /api/words", adminScope(models.ScopeWordsRead, wordHandler.GetAllWords))
+	mux.Handle("POST /api/words", adminScope(models.ScopeWordsWrite, wordHandler.CreateWord))
+	mux.Handle("PUT /api/words/{id}", adminScope(models.ScopeWordsWrite, wordHandler.UpdateWord))
+	mux.Handle("DELETE /api/words/{id}", adminScope(models.ScopeWordsWrite, wordHandler.DeleteWord))
+	mux.Handle("POST /api/words/{id}/revert/{revision}", adminScope(models.ScopeWordsWrite, wordHandler.RevertWord))
+	mux.Handle("POST /api/words/{id}/examples", adminScope(models.ScopeWordsWrite, wordHandler.AddExample))
+	mux.Handle("PUT /api/words/{id}/examples/{exampleId}", adminScope(models.ScopeWordsWrite, wordHandler.UpdateExample))
+	mux.Handle("DELETE /api/words/{id}/examples/{exampleId}", adminScope(models.ScopeWordsWrite, wordHandler.DeleteExample))
+	mux.Handle("POST /api/words/{id}/related", adminScope(models.ScopeWordsWrite, wordHandler.AddRelation))
+	mux.Handle("DELETE /api/words/{id}/related/{relatedId}", adminScope(models.ScopeWordsWrite, wordHandler.RemoveRelation))
+	mux.Handle("POST /api/words/{id}/audio", adminScope(models.ScopeWordsWrite, wordHandler.AddAudio))
+	mux.Handle("DELETE /api/words/{id}/audio/{audioId}", adminScope(models.ScopeWordsWrite, wordHandler.DeleteAudio))
 	// Select single word (shared for user/admin)
 	mux.HandleFunc("GET /api/words/selectone/{id}", wordHandler.SelectOneWord)
 
-	// Excel upload (authenticated route)
-	mux.Handle("POST /api/words/excel-upload", auth(http.HandlerFunc(wordHandler.ExcelCreateWords)))
+	// Word sub-resources. "GET /api/words/{id}/history" would conflict with the
+	// selectone route above (both match /api/words/selectone/history), so one
+	// pattern dispatches on the last segment instead.
+	wordViews := map[string]http.Handler{
+		"history":  adminScope(models.ScopeWordsRead, wordHandler.GetWordHistory),
+		"examples": adminScope(models.ScopeWordsRead, wordHandler.GetExamples),
+		"related":  readScope(models.ScopeWordsRead, wordHandler.GetRelatedWords), // shown to learners too
+		"kanji":    readScope(models.ScopeWordsRead, wordHandler.GetWordKanji),
+	}
+	mux.HandleFunc("GET /api/words/{id}/{view}", func(w http.ResponseWriter, r *http.Request) {
+		if h, ok := wordViews[r.PathValue("view")]; ok {
+			h.ServeHTTP(w, r)
+			return
+		}
+		http.NotFound(w, r)
+	})
+
+	// Excel upload (admin only)
+	mux.Handle("POST /api/words/excel-upload", adminScope(models.ScopeWordsWrite, wordHandler.ExcelCreateWords))
 
-	mux.Handle("GET /api/users/subscribed", auth(http.HandlerFunc(userHandler.GetSubscribedUsers)))
+	mux.Handle("GET /api/users/subscribed", adminScope(models.ScopeUsersRead, userHandler.GetSubscribedUsers))
 
 	// Admin: search users and update searches left
-	mux.Handle("GET /api/admin/users", auth(http.HandlerFunc(userHandler.GetAllUsers)))
-	mux.Handle("PUT /api/admin/users/searches-left", auth(http.HandlerFunc(userHandler.UpdateSearchesLeft)))
+	mux.Handle("GET /api/admin/users", adminScope(models.ScopeUsersRead, userHandler.GetAllUsers))
+	mux.Handle("PUT /api/admin/users/searches-left", adminScope(models.ScopeUsersWrite, userHandler.UpdateSearchesLeft))
+
+	// Admin: dictionaries. Import into one with the dictionaryId form field of
+	// excel-upload; export one with ?dictionaries= on the export.
+	mux.Handle("GET /api/admin/dictionaries", adminScope(models.ScopeWordsRead, dictionaryHandler.ListDictionaries))
+	mux.Handle("POST /api/admin/dictionaries", adminScope(models.ScopeWordsWrite, dictionaryHandler.CreateDictionary))
+	mux.Handle("PUT /api/admin/dictionaries/{id}", adminScope(models.ScopeWordsWrite, dictionaryHandler.UpdateDictionary))
+	mux.Handle("DELETE /api/admin/dictionaries/{id}", adminScope(models.ScopeWordsWrite, dictionaryHandler.DeleteDictionary))
+
+	// Admin: tags and Excel export
+	mux.Handle("GET /api/admin/tags", adminScope(models.ScopeWordsRead, tagHandler.ListTags))
+	mux.Handle("POST /api/admin/tags", adminScope(models.ScopeWordsWrite, tagHandler.CreateTag))
+	mux.Handle("PUT /api/admin/tags/{id}", adminScope(models.ScopeWordsWrite, tagHandler.UpdateTag))
+	mux.Handle("DELETE /api/admin/tags/{id}", adminScope(models.ScopeWordsWrite, tagHandler.DeleteTag))
+	mux.Handle("GET /api/admin/words/export", adminScope(models.ScopeWordsRead, wordHandler.ExportWords))
+
+	// Kanji dictionary: lookups for signed-in users, KANJIDIC2 import and Myanmar meanings for admins
+	mux.Handle("GET /api/kanji/{char}", readScope(models.ScopeWordsRead, kanjiHandler.GetKanji))
+	mux.Handle("POST /api/admin/kanji/import", adminScope(models.ScopeWordsWrite, kanjiHandler.ImportKanji))
+	mux.Handle("PUT /api/admin/kanji/{char}", adminScope(models.ScopeWordsWrite, kanjiHandler.UpdateKanji))
 
 	// Admin: duplicate words sync
-	mux.Handle("GET /api/admin/words/duplicates", auth(http.HandlerFunc(wordHandler.GetDuplicateWords)))
-	mux.Handle("PUT /api/admin/words/ignore", auth(http.HandlerFunc(wordHandler.SetWordIgnore)))
+	mux.Handle("GET /api/admin/words/duplicates", adminScope(models.ScopeWordsRead, wordHandler.GetDuplicateWords))
+	mux.Handle("PUT /api/admin/words/ignore", adminScope(models.ScopeWordsWrite, wordHandler.SetWordIgnore))
+
+	// Admin: deleted words stay in the trash for WORD_TRASH_RETENTION_DAYS
+	mux.Handle("GET /api/admin/words/trash", adminScope(models.ScopeWordsRead, wordHandler.GetTrash))
+	mux.Handle("POST /api/admin/words/trash/{id}/restore", adminScope(models.ScopeWordsWrite, wordHandler.RestoreWord))
+
+	// Admin: API keys for scripts (JWT only, a key cannot mint keys)
+	mux.Handle("GET /api/admin/api-keys", admin(apiKeyHandler.ListKeys))
+	mux.Handle("POST /api/admin/api-keys", admin(userID(apiKeyHandler.CreateKey)))
+	mux.Handle("DELETE /api/admin/api-keys/{id}", admin(apiKeyHandler.RevokeKey))
+
+	// Admin: deployment settings
+	mux.Handle("GET /api/admin/settings", admin(userHandler.GetAppSettings))
+	mux.Handle("PUT /api/admin/settings/two-factor", admin(userID(userHandler.SetRequireAdminTwoFactor)))
+
+	// Admin: audit log of word, user and settings changes
+	mux.Handle("GET /api/admin/audit", adminScope(models.ScopeAuditRead, auditHandler.GetAuditLogs))
 
 	// ===== Optional: Health Check =====
 	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"USDT_BackEnd/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// accountPurgeBatch is how many due accounts one purge pass handles at most.
+const accountPurgeBatch = 100
+
+// ScheduleAccountDeletion signs the user out everywhere and schedules the account
+// to be purged after the grace period. Signing in again before then cancels it.
+func (s *UserService) ScheduleAccountDeletion(ctx context.Context, userID primitive.ObjectID) (time.Time, error) {
+	log.Println("[DEBUG] ScheduleAccountDeletion called for userID:", userID.Hex())
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil || user == nil {
+		log.Println("[ERROR] User not found:", err)
+		return time.Time{}, errors.New("user not found")
+	}
+	if user.DeleteAfter != nil {
+		return *user.DeleteAfter, nil
+	}
+
+	deleteAfter := time.Now().AddDate(0, 0, s.config.AccountDeletionDays)
+	if err := s.repo.ScheduleDeletion(ctx, userID, deleteAfter); err != nil {
+		log.Println("[ERROR] Failed to schedule deletion:", err)
+		return time.Time{}, err
+	}
+
+	// Revoke tokens so nothing issued before the request keeps working
+	if err := s.invalidateAllTokens(ctx, userID); err != nil {
+		return time.Time{}, err
+	}
+
+	go SendEmail(user.Email, "Account deletion scheduled",
+		"Your account is scheduled for deletion on "+deleteAfter.Format("2 January 2006")+
+			". Sign in before then if you want to keep it.")
+	log.Println("[DEBUG] Account deletion scheduled for:", userID.Hex(), "at:", deleteAfter)
+	return deleteAfter, nil
+}
+
+// cancelScheduledDeletion is called on every successful sign-in.
+func (s *UserService) cancelScheduledDeletion(ctx context.Context, user *models.User) {
+	if user.DeleteAfter == nil {
+		return
+	}
+	cancelled, err := s.repo.CancelDeletion(ctx, user.ID)
+	if err != nil {
+		log.Println("[ERROR] Failed to cancel account deletion for:", user.ID.Hex(), "error:", err)
+		return
+	}
+	user.DeleteAfter = nil
+	if cancelled {
+		go SendEmail(user.Email, "Account deletion cancelled",
+			"You signed in, so your account will not be deleted.")
+		log.Println("[DEBUG] Account deletion cancelled by sign-in for:", user.ID.Hex())
+	}
+}
+
+// StartAccountPurge deletes accounts whose grace period has ended, checking once an hour.
+func (s *UserService) StartAccountPurge() {
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+			if err := s.purgeDueAccounts(ctx, time.Now()); err != nil {
+				log.Println("[ERROR] Account purge failed:", err)
+			}
+			cancel()
+			<-ticker.C
+		}
+	}()
+}
+
+func (s *UserService) purgeDueAccounts(ctx context.Context, now time.Time) error {
+	for {
+		users, err := s.repo.GetUsersDueForDeletion(ctx, now, accountPurgeBatch)
+		if err != nil {
+			return err
+		}
+		for i := range users {
+			if err := s.purgeAccount(ctx, &users[i], now); err != nil {
+				return err
+			}
+		}
+		if len(users) < accountPurgeBatch {
+			return nil
+		}
+	}
+}
+
+// purgeAccount deletes the user and every record that belongs to them. The user
+// document goes first, so a sign-in racing the purge either cancels it or fails.
+// Audit logs and word revisions are kept: they record admin actions, not user data.
+func (s *UserService) purgeAccount(ctx context.Context, user *models.User, now time.Time) error {
+	deleted, err := s.repo.DeleteScheduledUser(ctx, user.ID, now)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return nil
+	}
+
+	if err := s.sessions.DeleteAllForUser(ctx, user.ID); err != nil {
+		log.Println("[ERROR] Purge: failed to delete sessions of:", user.ID.Hex(), "error:", err)
+	}
+	if err := deleteOTPs(ctx, user.Email); err != nil {
+		log.Println("[ERROR] Purge: failed to delete OTPs of:", user.ID.Hex(), "error:", err)
+	}
+	if err := s.throttle.ForgetAccount(ctx, user.Email); err != nil {
+		log.Println("[ERROR] Purge: failed to delete login attempts of:", user.ID.Hex(), "error:", err)
+	}
+	if err := s.apiKeys.DeleteByCreator(ctx, user.ID); err != nil {
+		log.Println("[ERROR] Purge: failed to delete API keys of:", user.ID.Hex(), "error:", err)
+	}
+	if exports, err := s.exports.ListForUser(ctx, user.ID); err != nil {
+		log.Println("[ERROR] Purge: failed to list data exports of:", user.ID.Hex(), "error:", err)
+	} else {
+		s.deleteExports(ctx, exports)
+	}
+
+	go SendEmail(user.Email, "Account deleted",
+		"Your account and the data stored with it have been deleted.")
+	log.Println("[DEBUG] Account purged:", user.ID.Hex())
+	return nil
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"USDT_BackEnd/models"
+	"USDT_BackEnd/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// APIKeyPrefix starts every API key, so keys are easy to spot in logs and secret scanners
+// and can be told apart from JWTs in an Authorization header.
+const APIKeyPrefix = "usdt_"
+
+// lastUsedResolution limits how often a busy key writes its last-used time.
+const lastUsedResolution = time.Minute
+
+var (
+	ErrInvalidAPIKey  = errors.New("invalid or expired API key")
+	ErrAPIKeyNotFound = errors.New("API key not found")
+	ErrInvalidScope   = errors.New("unknown scope")
+)
+
+type APIKeyService struct {
+	repo *repository.APIKeyRepository
+}
+
+func NewAPIKeyService() *APIKeyService {
+	return &APIKeyService{repo: &repository.APIKeyRepository{}}
+}
+
+// CreateKey stores a new key and returns it together with the plaintext key,
+// which is never stored and cannot be shown again. expiresInDays 0 means no expiry.
+func (s *APIKeyService) CreateKey(ctx context.Context, creatorID primitive.ObjectID, name string, scopes []string, expiresInDays int) (*models.APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", errors.New("name is required")
+	}
+	if len(scopes) == 0 {
+		return nil, "", errors.New("at least one scope is required")
+	}
+	for _, scope := range scopes {
+		if !validScope(scope) {
+			return nil, "", ErrInvalidScope
+		}
+	}
+	if expiresInDays < 0 {
+		return nil, "", errors.New("expiresInDays cannot be negative")
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		log.Println("[ERROR] CreateKey: failed to generate key:", err)
+		return nil, "", err
+	}
+	plaintext := APIKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	now := time.Now()
+	key := &models.APIKey{
+		Name:      name,
+		Prefix:    plaintext[:len(APIKeyPrefix)+6],
+		KeyHash:   hashAPIKey(plaintext),
+		Scopes:    scopes,
+		CreatedBy: creatorID,
+		CreatedAt: now,
+	}
+	if expiresInDays > 0 {
+		expiresAt := now.AddDate(0, 0, expiresInDays)
+		key.ExpiresAt = &expiresAt
+	}
+	if err := s.repo.Create(ctx, key); err != nil {
+		log.Println("[ERROR] CreateKey: failed to store key:", err)
+		return nil, "", err
+	}
+
+	log.Println("[DEBUG] API key created:", key.Prefix, "by:", creatorID.Hex(), "scopes:", scopes)
+	return key, plaintext, nil
+}
+
+func (s *APIKeyService) ListKeys(ctx context.Context) ([]models.APIKey, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *APIKeyService) RevokeKey(ctx context.Context, idStr string) error {
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return ErrAPIKeyNotFound
+	}
+	revoked, err := s.repo.Revoke(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrAPIKeyNotFound
+	}
+	log.Println("[DEBUG] API key revoked:", idStr)
+	return nil
+}
+
+// Authenticate resolves a plaintext key to its record and records the use.
+func (s *APIKeyService) Authenticate(ctx context.Context, plaintext, ip string) (*models.APIKey, error) {
+	if !strings.HasPrefix(plaintext, APIKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+	key, err := s.repo.GetActiveByHash(ctx, hashAPIKey(plaintext))
+	if err != nil {
+		return nil, ErrInvalidAPIKey
+	}
+	now := time.Now()
+	if key.ExpiresAt != nil && now.After(*key.ExpiresAt) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > lastUsedResolution || key.LastUsedIP != ip {
+		if err := s.repo.TouchLastUsed(ctx, key.ID, ip, now); err != nil {
+			log.Println("[ERROR] Failed to record API key use:", key.Prefix, "error:", err)
+		}
+	}
+	return key, nil
+}
+
+func validScope(scope string) bool {
+	for _, s := range models.APIKeyScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// hashAPIKey uses plain SHA-256: keys carry 256 random bits, so a slow hash adds nothing.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
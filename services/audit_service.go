@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"log"
+	"reflect"
+	"time"
+
+	"USDT_BackEnd/models"
+	"USDT_BackEnd/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// auditIgnoredFields change on every write and would only add noise to a diff.
+var auditIgnoredFields = map[string]bool{"_id": true, "createdAt": true, "updatedAt": true}
+
+// AuditEvent describes one mutation. Before and After are the documents as stored
+// (structs or maps); either may be nil for creates and deletes.
+type AuditEvent struct {
+	Actor      models.AuditActor
+	IP         string
+	UserAgent  string
+	Action     string
+	TargetType string
+	TargetID   string
+	Before     interface{}
+	After      interface{}
+	Metadata   map[string]interface{}
+}
+
+type AuditService struct {
+	repo *repository.AuditRepository
+}
+
+func NewAuditService() *AuditService {
+	return &AuditService{repo: &repository.AuditRepository{}}
+}
+
+// Record stores the event with a field-level diff. Failures are logged, not returned:
+// the mutation has already happened and must not be reported as failed.
+func (s *AuditService) Record(ctx context.Context, e AuditEvent) {
+	entry := &models.AuditLog{
+		Actor:      e.Actor,
+		Action:     e.Action,
+		TargetType: e.TargetType,
+		TargetID:   e.TargetID,
+		Changes:    diffDocuments(e.Before, e.After),
+		Metadata:   e.Metadata,
+		IP:         e.IP,
+		UserAgent:  e.UserAgent,
+		CreatedAt:  time.Now(),
+	}
+	if err := s.repo.Insert(ctx, entry); err != nil {
+		log.Println("[ERROR] Failed to write audit log:", e.Action, e.TargetID, "error:", err)
+	}
+}
+
+// Query returns one page of audit entries and whether more follow.
+func (s *AuditService) Query(ctx context.Context, filter repository.AuditFilter, page, limit int) ([]models.AuditLog, bool, int64, error) {
+	logs, total, err := s.repo.Find(ctx, filter, page, limit)
+	if err != nil {
+		return nil, false, 0, err
+	}
+	return logs, int64(page*limit) < total, total, nil
+}
+
+// diffDocuments compares the BSON form of two documents field by field.
+func diffDocuments(before, after interface{}) map[string]models.AuditChange {
+	b, a := toFieldMap(before), toFieldMap(after)
+	changes := map[string]models.AuditChange{}
+	for k, bv := range b {
+		if auditIgnoredFields[k] {
+			continue
+		}
+		if av, ok := a[k]; !ok || !reflect.DeepEqual(bv, av) {
+			changes[k] = models.AuditChange{Before: bv, After: a[k]}
+		}
+	}
+	for k, av := range a {
+		if _, ok := b[k]; ok || auditIgnoredFields[k] {
+			continue
+		}
+		changes[k] = models.AuditChange{Before: nil, After: av}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return changes
+}
+
+func toFieldMap(doc interface{}) bson.M {
+	if doc == nil || (reflect.ValueOf(doc).Kind() == reflect.Ptr && reflect.ValueOf(doc).IsNil()) {
+		return bson.M{}
+	}
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		log.Println("[ERROR] Audit: cannot encode document:", err)
+		return bson.M{}
+	}
+	var m bson.M
+	if err := bson.Unmarshal(raw, &m); err != nil {
+		log.Println("[ERROR] Audit: cannot decode document:", err)
+		return bson.M{}
+	}
+	return m
+}
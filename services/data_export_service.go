@@ -0,0 +1,257 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"USDT_BackEnd/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	exportRetention  = 48 * time.Hour   // archive is kept this long after it is built
+	exportLinkTTL    = 15 * time.Minute // each download link works this long
+	exportJobTimeout = 30 * time.Minute // a job still pending after this is considered dead
+	exportBatchSize  = 500              // favorites resolved per query
+)
+
+// DataExportStatus is what the client polls: the export, plus a fresh download link once it is ready.
+type DataExportStatus struct {
+	*models.DataExport
+	DownloadURL   string `json:"downloadUrl,omitempty"`
+	LinkExpiresIn int64  `json:"linkExpiresIn,omitempty"` // seconds
+}
+
+// signInRecord is one signed-in device, collapsed from its rotated refresh tokens.
+type signInRecord struct {
+	SignedInAt time.Time `json:"signedInAt"`
+	LastUsedAt time.Time `json:"lastUsedAt"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"userAgent"`
+	Active     bool      `json:"active"`
+}
+
+// RequestDataExport returns the user's current export. A ready archive comes with a
+// new download link; if there is none, or the last one expired or failed, a new
+// export is started in the background and returned as pending.
+func (s *UserService) RequestDataExport(ctx context.Context, userID primitive.ObjectID) (*DataExportStatus, error) {
+	latest, err := s.exports.GetLatestForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+
+	if latest != nil {
+		switch {
+		case latest.Status == models.ExportReady && latest.ExpiresAt != nil && now.Before(*latest.ExpiresAt):
+			url, err := NewStorageService().PresignedURL(latest.FileKey, exportLinkTTL)
+			if err != nil {
+				log.Println("[ERROR] Failed to sign export link for:", userID.Hex(), "error:", err)
+				return nil, err
+			}
+			return &DataExportStatus{DataExport: latest, DownloadURL: url, LinkExpiresIn: int64(exportLinkTTL.Seconds())}, nil
+		case latest.Status == models.ExportPending && now.Sub(latest.CreatedAt) < exportJobTimeout:
+			return &DataExportStatus{DataExport: latest}, nil
+		}
+	}
+
+	export := &models.DataExport{UserID: userID, Status: models.ExportPending, CreatedAt: now}
+	if err := s.exports.Create(ctx, export); err != nil {
+		log.Println("[ERROR] Failed to create export for:", userID.Hex(), "error:", err)
+		return nil, err
+	}
+	go s.runDataExport(export)
+
+	log.Println("[DEBUG] Data export started for:", userID.Hex())
+	return &DataExportStatus{DataExport: export}, nil
+}
+
+// runDataExport builds the archive, stores it privately and emails the user.
+func (s *UserService) runDataExport(export *models.DataExport) {
+	ctx, cancel := context.WithTimeout(context.Background(), exportJobTimeout)
+	defer cancel()
+
+	user, archive, err := s.buildDataExport(ctx, export.UserID)
+	if err == nil {
+		key := fmt.Sprintf("exports/%s/%s.zip", export.UserID.Hex(), export.ID.Hex())
+		if err = NewStorageService().UploadPrivateFile(key, archive, "application/zip"); err == nil {
+			now := time.Now()
+			err = s.exports.MarkReady(ctx, export.ID, key, int64(len(archive)), now, now.Add(exportRetention))
+		}
+	}
+	if err != nil {
+		log.Println("[ERROR] Data export failed for:", export.UserID.Hex(), "error:", err)
+		if markErr := s.exports.MarkFailed(ctx, export.ID, "export could not be created, please try again"); markErr != nil {
+			log.Println("[ERROR] Failed to mark export as failed:", markErr)
+		}
+		return
+	}
+
+	SendEmail(user.Email, "Your data export is ready",
+		"The copy of your data you requested is ready. Open the app and request the export again to download it. "+
+			"It will be deleted after "+fmt.Sprint(exportRetention.Hours())+" hours.")
+	log.Println("[DEBUG] Data export ready for:", export.UserID.Hex(), "bytes:", len(archive))
+}
+
+// buildDataExport returns a zip with export.json (everything) and CSV copies of the lists.
+func (s *UserService) buildDataExport(ctx context.Context, userID primitive.ObjectID) (*models.User, []byte, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil || user == nil {
+		return nil, nil, errors.New("user not found")
+	}
+
+	favorites := []models.Word{}
+	for start := 0; start < len(user.Favorites); start += exportBatchSize {
+		end := min(start+exportBatchSize, len(user.Favorites))
+		words, err := s.repo.GetFavorites(ctx, user.Favorites[start:end])
+		if err != nil {
+			return nil, nil, err
+		}
+		favorites = append(favorites, words...)
+	}
+
+	sessions, err := s.sessions.ListAllForUser(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	history := signInHistory(sessions, time.Now())
+
+	data := map[string]interface{}{
+		"exportedAt": time.Now(),
+		"profile": map[string]interface{}{
+			"id":                 user.ID.Hex(),
+			"email":              user.Email,
+			"role":               user.Role,
+			"emailVerified":      user.EmailVerified,
+			"hasPassword":        user.HasPassword(),
+			"identities":         user.Identities,
+			"twoFactorEnabled":   user.TwoFactor.Enabled,
+			"searchDictionaries": user.SearchDictionaries,
+			"deleteAfter":        user.DeleteAfter,
+			"createdAt":          user.CreatedAt,
+			"updatedAt":          user.UpdatedAt,
+		},
+		"subscription":  user.Subscription,
+		"favorites":     favorites,
+		"signInHistory": history,
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	f, err := zw.Create("export.json")
+	if err != nil {
+		return nil, nil, err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(data); err != nil {
+		return nil, nil, err
+	}
+
+	favoriteRows := [][]string{{"id", "japanese", "subTerm", "english", "myanmar", "imageUrl"}}
+	for _, w := range favorites {
+		favoriteRows = append(favoriteRows, []string{w.ID.Hex(), w.Japanese, w.SubTerm, w.English, w.Myanmar, w.ImageURL})
+	}
+	if err := writeZipCSV(zw, "favorites.csv", favoriteRows); err != nil {
+		return nil, nil, err
+	}
+
+	historyRows := [][]string{{"signedInAt", "lastUsedAt", "ip", "userAgent", "active"}}
+	for _, h := range history {
+		historyRows = append(historyRows, []string{
+			h.SignedInAt.Format(time.RFC3339), h.LastUsedAt.Format(time.RFC3339), h.IP, h.UserAgent, fmt.Sprint(h.Active),
+		})
+	}
+	if err := writeZipCSV(zw, "sign_in_history.csv", historyRows); err != nil {
+		return nil, nil, err
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, nil, err
+	}
+	return user, buf.Bytes(), nil
+}
+
+// writeZipCSV adds a CSV file with a UTF-8 BOM, so Excel shows Japanese and Myanmar text correctly.
+func writeZipCSV(zw *zip.Writer, name string, rows [][]string) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write([]byte("\ufeff")); err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	if err := w.WriteAll(rows); err != nil {
+		return err
+	}
+	return w.Error()
+}
+
+// signInHistory collapses session records (oldest first) into one entry per sign-in.
+func signInHistory(sessions []models.Session, now time.Time) []signInRecord {
+	index := map[primitive.ObjectID]int{}
+	history := []signInRecord{}
+	for _, sess := range sessions {
+		active := sess.RevokedAt == nil && sess.RotatedAt == nil && now.Before(sess.ExpiresAt)
+		i, ok := index[sess.FamilyID]
+		if !ok {
+			index[sess.FamilyID] = len(history)
+			history = append(history, signInRecord{SignedInAt: sess.SignedInAt})
+			i = len(history) - 1
+		}
+		history[i].LastUsedAt = sess.CreatedAt
+		history[i].IP = sess.IP
+		history[i].UserAgent = sess.UserAgent
+		history[i].Active = active
+	}
+	return history
+}
+
+// StartExportCleanup deletes expired and abandoned export archives once an hour.
+func (s *UserService) StartExportCleanup() {
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			now := time.Now()
+			exports, err := s.exports.GetExpired(ctx, now, now.Add(-exportJobTimeout))
+			if err != nil {
+				log.Println("[ERROR] Export cleanup failed:", err)
+			} else {
+				s.deleteExports(ctx, exports)
+			}
+			cancel()
+			<-ticker.C
+		}
+	}()
+}
+
+// deleteExports removes the archives from storage, then their records.
+func (s *UserService) deleteExports(ctx context.Context, exports []models.DataExport) {
+	if len(exports) == 0 {
+		return
+	}
+	storage := NewStorageService()
+	for _, export := range exports {
+		if export.FileKey != "" {
+			if err := storage.DeleteFile(export.FileKey); err != nil {
+				log.Println("[ERROR] Failed to delete export file:", export.FileKey, "error:", err)
+				continue
+			}
+		}
+		if err := s.exports.Delete(ctx, export.ID); err != nil {
+			log.Println("[ERROR] Failed to delete export record:", export.ID.Hex(), "error:", err)
+		}
+	}
+}
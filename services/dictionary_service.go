@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"USDT_BackEnd/models"
+	"USDT_BackEnd/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// maxDictionaryNameLength is the longest dictionary name accepted, in characters.
+const maxDictionaryNameLength = 80
+
+var (
+	ErrDictionaryNotFound    = errors.New("dictionary not found")
+	ErrDictionaryExists      = errors.New("a dictionary with this name already exists")
+	ErrInvalidDictionaryName = errors.New("dictionary name must be 1-80 characters")
+	ErrDictionaryNotEmpty    = errors.New("dictionary still has words; move or delete them first")
+	ErrDefaultDictionary     = errors.New("the default dictionary cannot be deleted")
+	ErrUnknownDictionary     = errors.New("unknown dictionary")
+)
+
+type DictionaryService struct {
+	repo  *repository.DictionaryRepository
+	words *repository.WordRepository
+	users *repository.UserRepository
+}
+
+func NewDictionaryService() *DictionaryService {
+	return &DictionaryService{
+		repo:  &repository.DictionaryRepository{},
+		words: &repository.WordRepository{},
+		users: &repository.UserRepository{},
+	}
+}
+
+// ListDictionaries returns every dictionary with the number of words in it.
+func (s *DictionaryService) ListDictionaries(ctx context.Context) ([]models.Dictionary, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *DictionaryService) CreateDictionary(ctx context.Context, name, description string) (*models.Dictionary, error) {
+	name, err := normalizeDictionaryName(name)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	dictionary := &models.Dictionary{
+		Name:        name,
+		Description: strings.TrimSpace(description),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.repo.Create(ctx, dictionary); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrDictionaryExists
+		}
+		return nil, err
+	}
+	log.Println("[DEBUG] Dictionary created:", name)
+	return dictionary, nil
+}
+
+// UpdateDictionary changes a dictionary's name and description and returns it before and after.
+func (s *DictionaryService) UpdateDictionary(ctx context.Context, idStr, name, description string) (before, after *models.Dictionary, err error) {
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return nil, nil, ErrDictionaryNotFound
+	}
+	name, err = normalizeDictionaryName(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	before, err = s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, ErrDictionaryNotFound
+	}
+
+	updated := *before
+	updated.Name = name
+	updated.Description = strings.TrimSpace(description)
+	found, err := s.repo.Update(ctx, &updated)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, nil, ErrDictionaryExists
+		}
+		return nil, nil, err
+	}
+	if !found {
+		return nil, nil, ErrDictionaryNotFound
+	}
+	return before, &updated, nil
+}
+
+// DeleteDictionary removes an empty dictionary, other than the default, and
+// takes it out of every user's search default. It returns the deleted dictionary.
+func (s *DictionaryService) DeleteDictionary(ctx context.Context, idStr string) (*models.Dictionary, error) {
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return nil, ErrDictionaryNotFound
+	}
+	dictionary, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrDictionaryNotFound
+	}
+	if dictionary.IsDefault {
+		return nil, ErrDefaultDictionary
+	}
+	// Words in the trash count too: restoring them needs the dictionary.
+	count, err := s.words.CountInDictionary(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrDictionaryNotEmpty
+	}
+	deleted, err := s.repo.Delete(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !deleted {
+		return nil, ErrDictionaryNotFound
+	}
+
+	if err := s.users.RemoveSearchDictionary(ctx, id); err != nil {
+		log.Println("[ERROR] Failed to remove deleted dictionary from search defaults:", idStr, "error:", err)
+		return nil, err
+	}
+	log.Println("[DEBUG] Dictionary deleted:", dictionary.Name)
+	return dictionary, nil
+}
+
+// GetSearchDictionaries returns the dictionaries the user searches by default;
+// empty means all of them.
+func (s *DictionaryService) GetSearchDictionaries(ctx context.Context, userID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	user, err := s.users.GetUserByID(ctx, userID)
+	if err != nil || user == nil {
+		return nil, errors.New("user not found")
+	}
+	if user.SearchDictionaries == nil {
+		return []primitive.ObjectID{}, nil
+	}
+	return user.SearchDictionaries, nil
+}
+
+// SetSearchDictionaries saves the dictionaries the user searches by default. An
+// empty list searches all of them.
+func (s *DictionaryService) SetSearchDictionaries(ctx context.Context, userID primitive.ObjectID, idStrs []string) ([]primitive.ObjectID, error) {
+	ids, err := parseDictionaryIDs(idStrs)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDictionariesExist(ctx, s.repo, ids); err != nil {
+		return nil, err
+	}
+	if err := s.users.SetSearchDictionaries(ctx, userID, ids); err != nil {
+		return nil, err
+	}
+	log.Println("[DEBUG] Search dictionaries set for:", userID.Hex(), "count:", len(ids))
+	return ids, nil
+}
+
+// parseDictionaryIDs parses dictionary IDs, dropping blanks and repeats.
+func parseDictionaryIDs(idStrs []string) ([]primitive.ObjectID, error) {
+	ids := []primitive.ObjectID{}
+	for _, idStr := range idStrs {
+		idStr = strings.TrimSpace(idStr)
+		if idStr == "" {
+			continue
+		}
+		id, err := primitive.ObjectIDFromHex(idStr)
+		if err != nil {
+			return nil, ErrUnknownDictionary
+		}
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// checkDictionariesExist returns ErrUnknownDictionary unless every ID is a dictionary.
+func checkDictionariesExist(ctx context.Context, repo *repository.DictionaryRepository, ids []primitive.ObjectID) error {
+	count, err := repo.CountExisting(ctx, ids)
+	if err != nil {
+		return err
+	}
+	if count != int64(len(ids)) {
+		return ErrUnknownDictionary
+	}
+	return nil
+}
+
+func normalizeDictionaryName(name string) (string, error) {
+	name = strings.Join(strings.Fields(name), " ")
+	if name == "" || utf8.RuneCountInString(name) > maxDictionaryNameLength {
+		return "", ErrInvalidDictionaryName
+	}
+	return name, nil
+}
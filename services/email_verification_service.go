@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+)
+
+// SendVerificationOTP emails a fresh verification code to an unverified account.
+// It does nothing for unknown or already verified emails. Every request counts
+// against the per-address and per-IP send limits, whatever the email.
+func (s *UserService) SendVerificationOTP(ctx context.Context, email string, client ClientInfo) error {
+	log.Println("[DEBUG] SendVerificationOTP called for:", email)
+	acctLimit, ipLimit := sendKeys("verify", email, client.IP)
+	if err := s.throttle.Attempt(ctx, acctLimit, ipLimit); err != nil {
+		log.Println("[DEBUG] SendVerificationOTP throttled for:", email, "ip:", client.IP)
+		return err
+	}
+	return s.sendVerificationOTP(ctx, email)
+}
+
+// sendVerificationOTP is SendVerificationOTP without the send limits, for Register.
+func (s *UserService) sendVerificationOTP(ctx context.Context, email string) error {
+	user, err := s.repo.GetUserByEmail(ctx, email)
+	if err != nil || user == nil {
+		log.Println("[DEBUG] SendVerificationOTP: user not found:", email)
+		return nil
+	}
+	if user.EmailVerified {
+		log.Println("[DEBUG] SendVerificationOTP: already verified:", email)
+		return nil
+	}
+
+	otp, err := generateOTP()
+	if err != nil {
+		log.Println("[ERROR] SendVerificationOTP: failed to generate OTP:", err)
+		return err
+	}
+	if err := s.storeOTP(ctx, "email_verifications", email, otp); err != nil {
+		log.Println("[ERROR] SendVerificationOTP: failed to store OTP:", err)
+		return err
+	}
+
+	go SendEmail(email, "Verify your email", "Your verification code: "+otp)
+	log.Println("[DEBUG] SendVerificationOTP: OTP email sent to:", email)
+	return nil
+}
+
+// VerifyEmail marks the account as verified when the code matches.
+func (s *UserService) VerifyEmail(ctx context.Context, email, otp string, client ClientInfo) error {
+	log.Println("[DEBUG] VerifyEmail called for:", email)
+
+	acctLimit, ipLimit := accountKey("verify", email), ipKey("verify", client.IP)
+	if err := s.throttle.Attempt(ctx, acctLimit, ipLimit); err != nil {
+		log.Println("[DEBUG] VerifyEmail throttled for:", email, "ip:", client.IP)
+		return err
+	}
+
+	if err := s.consumeOTP(ctx, "email_verifications", email, otp); err != nil {
+		log.Println("[ERROR] VerifyEmail: OTP rejected for:", email, "error:", err)
+		return err
+	}
+	s.throttle.Succeed(ctx, acctLimit)
+	s.throttle.Uncount(ctx, ipLimit)
+
+	if err := s.repo.SetEmailVerified(ctx, email); err != nil {
+		log.Println("[ERROR] VerifyEmail: failed to update user:", err)
+		return errors.New("failed to verify email")
+	}
+
+	log.Println("[DEBUG] VerifyEmail: email verified for:", email)
+	return nil
+}
@@ -0,0 +1,349 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"USDT_BackEnd/config"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/api/idtoken"
+)
+
+var (
+	ErrUnknownProvider      = errors.New("unknown identity provider")
+	ErrInvalidIdentityToken = errors.New("invalid identity token")
+)
+
+// ExternalIdentity is what a provider vouches for after verifying its ID token.
+type ExternalIdentity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+// IdentityProvider verifies ID tokens issued by one external sign-in provider.
+type IdentityProvider interface {
+	Name() string
+	Verify(ctx context.Context, token string) (*ExternalIdentity, error)
+}
+
+// NewIdentityProviders builds the providers enabled in the config, keyed by name.
+// Google is always on; Apple and the generic OIDC provider only when configured.
+func NewIdentityProviders(cfg *config.Config) map[string]IdentityProvider {
+	providers := map[string]IdentityProvider{}
+	add := func(p IdentityProvider) { providers[p.Name()] = p }
+
+	add(&googleProvider{clientIDs: cfg.GoogleClientIDs})
+	if len(cfg.AppleClientIDs) > 0 {
+		add(NewOIDCProvider("apple", "https://appleid.apple.com", cfg.AppleClientIDs, "https://appleid.apple.com/auth/keys", nil))
+	}
+	if cfg.OIDCIssuer != "" && len(cfg.OIDCClientIDs) > 0 {
+		add(NewOIDCProvider(cfg.OIDCProviderName, cfg.OIDCIssuer, cfg.OIDCClientIDs, cfg.OIDCJWKSURL, nil))
+	}
+
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	log.Println("🔐 Identity providers enabled:", strings.Join(names, ", "))
+	return providers
+}
+
+// EmailLinkingProviders names the providers trusted to link a first sign-in to an
+// existing account by email alone. Google and Apple are; the generic OIDC issuer is
+// only when OIDC_LINK_BY_EMAIL is set, since whoever runs it could claim any address.
+func EmailLinkingProviders(cfg *config.Config) map[string]bool {
+	trusted := map[string]bool{"google": true, "apple": true}
+	if cfg.OIDCLinkByEmail {
+		trusted[cfg.OIDCProviderName] = true
+	}
+	return trusted
+}
+
+// ------------------- Google -------------------
+
+// googleProvider uses Google's idtoken package, which also handles Google's key rotation.
+type googleProvider struct {
+	clientIDs []string
+}
+
+func (p *googleProvider) Name() string { return "google" }
+
+func (p *googleProvider) Verify(ctx context.Context, token string) (*ExternalIdentity, error) {
+	log.Println("[DEBUG] googleProvider: trying", len(p.clientIDs), "client IDs")
+	for _, cid := range p.clientIDs {
+		payload, err := idtoken.Validate(ctx, token, cid)
+		if err != nil {
+			log.Println("[DEBUG] googleProvider: failed for client ID:", maskedClientID(cid), "error:", err)
+			continue
+		}
+		log.Println("[DEBUG] googleProvider: matched client ID:", maskedClientID(cid))
+		email, _ := payload.Claims["email"].(string)
+		verified, _ := payload.Claims["email_verified"].(bool)
+		return &ExternalIdentity{
+			Provider:      p.Name(),
+			Subject:       payload.Subject,
+			Email:         email,
+			EmailVerified: verified,
+		}, nil
+	}
+	log.Println("[ERROR] googleProvider: no matching client ID found")
+	return nil, ErrInvalidIdentityToken
+}
+
+func maskedClientID(cid string) string {
+	if len(cid) <= 20 {
+		return cid
+	}
+	return cid[:14] + "..." + cid[len(cid)-6:]
+}
+
+// ------------------- Generic OIDC (also used for Apple) -------------------
+
+// An unknown kid refetches the JWKS at most every jwksRefreshInterval, or every
+// jwksRetryInterval while fetching fails.
+const (
+	jwksRefreshInterval = time.Minute
+	jwksRetryInterval   = 5 * time.Second
+)
+
+// oidcProvider verifies RS256/ES256 ID tokens against the issuer's published JWKS.
+type oidcProvider struct {
+	name      string
+	issuer    string
+	clientIDs []string
+	jwksURL   string
+	client    *http.Client
+
+	fetchMu   sync.Mutex // one JWKS fetch at a time; also guards jwksURL
+	mu        sync.Mutex // guards keys and nextFetch, never held over the network
+	keys      map[string]interface{}
+	nextFetch time.Time
+}
+
+// NewOIDCProvider returns a provider for any OpenID Connect issuer. When jwksURL is
+// empty it is discovered from the issuer's /.well-known/openid-configuration.
+// httpClient may be nil; tests can pass one that talks to a local mock issuer.
+func NewOIDCProvider(name, issuer string, clientIDs []string, jwksURL string, httpClient *http.Client) IdentityProvider {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &oidcProvider{
+		name:      name,
+		issuer:    strings.TrimRight(issuer, "/"),
+		clientIDs: clientIDs,
+		jwksURL:   jwksURL,
+		client:    httpClient,
+	}
+}
+
+func (p *oidcProvider) Name() string { return p.name }
+
+func (p *oidcProvider) Verify(ctx context.Context, token string) (*ExternalIdentity, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.key(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "ES256"}),
+		jwt.WithIssuer(p.issuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(30*time.Second),
+	)
+	if err != nil {
+		log.Println("[DEBUG] oidcProvider", p.name+": token rejected:", err)
+		return nil, ErrInvalidIdentityToken
+	}
+
+	audiences, _ := claims.GetAudience()
+	if !matchesAny(audiences, p.clientIDs) {
+		log.Println("[DEBUG] oidcProvider", p.name+": audience not accepted:", audiences)
+		return nil, ErrInvalidIdentityToken
+	}
+
+	subject, _ := claims.GetSubject()
+	if subject == "" {
+		return nil, ErrInvalidIdentityToken
+	}
+	email, _ := claims["email"].(string)
+
+	// Apple sends email_verified as the string "true".
+	var verified bool
+	switch v := claims["email_verified"].(type) {
+	case bool:
+		verified = v
+	case string:
+		verified = v == "true"
+	}
+
+	return &ExternalIdentity{
+		Provider:      p.name,
+		Subject:       subject,
+		Email:         email,
+		EmailVerified: verified,
+	}, nil
+}
+
+// key returns the public key for kid, refetching the JWKS when the kid is unknown.
+// Tokens with known kids never wait for a fetch.
+func (p *oidcProvider) key(ctx context.Context, kid string) (interface{}, error) {
+	if key, ok := p.cachedKey(kid); ok {
+		return key, nil
+	}
+
+	p.fetchMu.Lock()
+	defer p.fetchMu.Unlock()
+	// Another request may have fetched the key while this one waited.
+	if key, ok := p.cachedKey(kid); ok {
+		return key, nil
+	}
+	p.mu.Lock()
+	wait := time.Now().Before(p.nextFetch)
+	p.mu.Unlock()
+	if wait {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	keys, err := p.fetchKeys(ctx)
+	p.mu.Lock()
+	if err != nil {
+		p.nextFetch = time.Now().Add(jwksRetryInterval)
+		p.mu.Unlock()
+		log.Println("[ERROR] oidcProvider", p.name+": failed to fetch JWKS:", err)
+		return nil, err
+	}
+	p.keys = keys
+	p.nextFetch = time.Now().Add(jwksRefreshInterval)
+	p.mu.Unlock()
+
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (p *oidcProvider) cachedKey(kid string) (interface{}, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+func (p *oidcProvider) fetchKeys(ctx context.Context) (map[string]interface{}, error) {
+	if p.jwksURL == "" {
+		var discovery struct {
+			JWKSURI string `json:"jwks_uri"`
+		}
+		if err := p.getJSON(ctx, p.issuer+"/.well-known/openid-configuration", &discovery); err != nil {
+			return nil, err
+		}
+		if discovery.JWKSURI == "" {
+			return nil, errors.New("discovery document has no jwks_uri")
+		}
+		p.jwksURL = discovery.JWKSURI
+	}
+
+	var set struct {
+		Keys []rawJWK `json:"keys"`
+	}
+	if err := p.getJSON(ctx, p.jwksURL, &set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			log.Println("[DEBUG] oidcProvider", p.name+": skipping key", k.Kid+":", err)
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
+
+func (p *oidcProvider) getJSON(ctx context.Context, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// rawJWK is an RSA or EC public key as published in a JWKS.
+type rawJWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k rawJWK) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func matchesAny(values, accepted []string) bool {
+	for _, v := range values {
+		for _, a := range accepted {
+			if v == a {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testClientID = "client-1"
+
+// mockIssuer is a local OpenID Connect issuer serving discovery and a JWKS whose
+// keys can be changed mid-test.
+type mockIssuer struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	keys        map[string]*rsa.PrivateKey
+	jwksFetches int
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	t.Helper()
+	m := &mockIssuer{keys: map[string]*rsa.PrivateKey{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":   m.URL,
+			"jwks_uri": m.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.jwksFetches++
+		keys := []rawJWK{}
+		for kid, key := range m.keys {
+			keys = append(keys, rawJWK{
+				Kty: "RSA",
+				Kid: kid,
+				Use: "sig",
+				N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+	})
+	m.Server = httptest.NewServer(mux)
+	t.Cleanup(m.Close)
+	return m
+}
+
+// addKey publishes a new RSA key under kid.
+func (m *mockIssuer) addKey(t *testing.T, kid string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.mu.Lock()
+	m.keys[kid] = key
+	m.mu.Unlock()
+}
+
+func (m *mockIssuer) fetches() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.jwksFetches
+}
+
+// sign issues a token with kid over the given claims.
+func (m *mockIssuer) sign(t *testing.T, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	m.mu.Lock()
+	key := m.keys[kid]
+	m.mu.Unlock()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+// claims returns valid claims for the issuer, with overrides applied.
+func (m *mockIssuer) claims(overrides jwt.MapClaims) jwt.MapClaims {
+	claims := jwt.MapClaims{
+		"iss":            m.URL,
+		"aud":            testClientID,
+		"sub":            "user-1",
+		"email":          "user@example.com",
+		"email_verified": true,
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(time.Hour).Unix(),
+	}
+	for k, v := range overrides {
+		claims[k] = v
+	}
+	return claims
+}
+
+func newTestProvider(m *mockIssuer) *oidcProvider {
+	return NewOIDCProvider("school", m.URL, []string{testClientID}, "", m.Client()).(*oidcProvider)
+}
+
+func TestOIDCProviderVerify(t *testing.T) {
+	issuer := newMockIssuer(t)
+	issuer.addKey(t, "key-1")
+	provider := newTestProvider(issuer)
+
+	tests := []struct {
+		name      string
+		overrides jwt.MapClaims
+		wantErr   bool
+		verified  bool
+	}{
+		{name: "valid token", verified: true},
+		{name: "wrong audience", overrides: jwt.MapClaims{"aud": "someone-else"}, wantErr: true},
+		{name: "wrong issuer", overrides: jwt.MapClaims{"iss": "https://evil.example.com"}, wantErr: true},
+		{name: "expired", overrides: jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()}, wantErr: true},
+		{name: "apple string email_verified true", overrides: jwt.MapClaims{"email_verified": "true"}, verified: true},
+		{name: "apple string email_verified false", overrides: jwt.MapClaims{"email_verified": "false"}, verified: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := issuer.sign(t, "key-1", issuer.claims(tt.overrides))
+			identity, err := provider.Verify(context.Background(), token)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidIdentityToken) {
+					t.Fatalf("Verify error = %v, want ErrInvalidIdentityToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if identity.Provider != "school" || identity.Subject != "user-1" || identity.Email != "user@example.com" {
+				t.Errorf("identity = %+v", identity)
+			}
+			if identity.EmailVerified != tt.verified {
+				t.Errorf("EmailVerified = %v, want %v", identity.EmailVerified, tt.verified)
+			}
+		})
+	}
+}
+
+func TestOIDCProviderRefetchesOnUnknownKid(t *testing.T) {
+	issuer := newMockIssuer(t)
+	issuer.addKey(t, "key-1")
+	provider := newTestProvider(issuer)
+	ctx := context.Background()
+
+	if _, err := provider.Verify(ctx, issuer.sign(t, "key-1", issuer.claims(nil))); err != nil {
+		t.Fatalf("Verify with key-1: %v", err)
+	}
+	if got := issuer.fetches(); got != 1 {
+		t.Fatalf("JWKS fetched %d times, want 1", got)
+	}
+
+	// The issuer rotates in a new key. Until the refresh interval has passed, the
+	// unknown kid is rejected without hitting the issuer again.
+	issuer.addKey(t, "key-2")
+	rotated := issuer.sign(t, "key-2", issuer.claims(nil))
+	if _, err := provider.Verify(ctx, rotated); !errors.Is(err, ErrInvalidIdentityToken) {
+		t.Fatalf("Verify within the refresh interval: error = %v, want ErrInvalidIdentityToken", err)
+	}
+	if got := issuer.fetches(); got != 1 {
+		t.Fatalf("JWKS fetched %d times within the refresh interval, want 1", got)
+	}
+
+	provider.mu.Lock()
+	provider.nextFetch = time.Time{}
+	provider.mu.Unlock()
+	if _, err := provider.Verify(ctx, rotated); err != nil {
+		t.Fatalf("Verify with key-2 after refetch: %v", err)
+	}
+	if got := issuer.fetches(); got != 2 {
+		t.Fatalf("JWKS fetched %d times, want 2", got)
+	}
+
+	// Known kids are served from the cache.
+	if _, err := provider.Verify(ctx, issuer.sign(t, "key-1", issuer.claims(nil))); err != nil {
+		t.Fatalf("Verify with key-1 after refetch: %v", err)
+	}
+	if got := issuer.fetches(); got != 2 {
+		t.Fatalf("JWKS fetched %d times, want 2", got)
+	}
+}
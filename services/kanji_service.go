@@ -0,0 +1,259 @@
+package services
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"USDT_BackEnd/models"
+	"USDT_BackEnd/repository"
+)
+
+// kanjiImportBatch is how many entries are written per bulk upsert.
+const kanjiImportBatch = 1000
+
+const (
+	MaxKanjidicUploadBytes = 32 << 20 // largest accepted upload, plain or gzipped
+	maxKanjidicXMLBytes    = 64 << 20 // largest XML after decompressing; the full KANJIDIC2 is about 15 MB
+)
+
+var (
+	ErrKanjiNotFound     = errors.New("kanji not found")
+	ErrNotKanji          = errors.New("expected a single kanji character")
+	ErrInvalidKanjidic   = errors.New("file has no KANJIDIC2 <character> entries")
+	ErrMalformedKanjidic = errors.New("file is not valid KANJIDIC2 XML")
+	ErrKanjidicTooLarge  = fmt.Errorf("KANJIDIC2 XML must be at most %d MB", maxKanjidicXMLBytes>>20)
+	kanjidicJLPTLevels   = map[int]string{4: "N5", 3: "N4", 2: "N2", 1: "N1"}
+	kanjidicReadingType  = map[string]bool{"ja_on": true, "ja_kun": true}
+)
+
+type KanjiService struct {
+	repo *repository.KanjiRepository
+}
+
+func NewKanjiService() *KanjiService {
+	return &KanjiService{repo: &repository.KanjiRepository{}}
+}
+
+// KanjiRef is one kanji of a word, with its dictionary entry if there is one.
+type KanjiRef struct {
+	Character string        `json:"character"`
+	Entry     *models.Kanji `json:"entry,omitempty"`
+}
+
+// KanjiImportResult counts what a KANJIDIC2 import did.
+type KanjiImportResult struct {
+	Parsed   int   `json:"parsed"`
+	Inserted int64 `json:"inserted"`
+	Updated  int64 `json:"updated"`
+}
+
+func (s *KanjiService) GetKanji(ctx context.Context, character string) (*models.Kanji, error) {
+	if !isSingleKanji(character) {
+		return nil, ErrNotKanji
+	}
+	kanji, err := s.repo.GetByCharacter(ctx, character)
+	if err != nil {
+		return nil, ErrKanjiNotFound
+	}
+	return kanji, nil
+}
+
+// UpdateMyanmarMeanings replaces the Myanmar meanings of a kanji and returns the
+// entry before and after.
+func (s *KanjiService) UpdateMyanmarMeanings(ctx context.Context, character string, meanings []string) (before, after *models.Kanji, err error) {
+	before, err = s.GetKanji(ctx, character)
+	if err != nil {
+		return nil, nil, err
+	}
+	cleaned := []string{}
+	for _, m := range meanings {
+		if m = strings.TrimSpace(m); m != "" {
+			cleaned = append(cleaned, m)
+		}
+	}
+	after, err = s.repo.UpdateMyanmarMeanings(ctx, character, cleaned)
+	if err != nil {
+		return nil, nil, err
+	}
+	log.Println("[DEBUG] Myanmar meanings updated for kanji:", character)
+	return before, after, nil
+}
+
+// Breakdown lists the distinct kanji of japanese in order, each linked to its
+// entry. Kanji missing from the dictionary are listed without one.
+func (s *KanjiService) Breakdown(ctx context.Context, japanese string) ([]KanjiRef, error) {
+	refs := []KanjiRef{}
+	seen := map[rune]bool{}
+	var characters []string
+	for _, r := range japanese {
+		if unicode.Is(unicode.Han, r) && r != '々' && !seen[r] {
+			seen[r] = true
+			refs = append(refs, KanjiRef{Character: string(r)})
+			characters = append(characters, string(r))
+		}
+	}
+	if len(characters) == 0 {
+		return refs, nil
+	}
+
+	entries, err := s.repo.GetByCharacters(ctx, characters)
+	if err != nil {
+		return nil, err
+	}
+	byCharacter := make(map[string]*models.Kanji, len(entries))
+	for i := range entries {
+		byCharacter[entries[i].Character] = &entries[i]
+	}
+	for i := range refs {
+		refs[i].Entry = byCharacter[refs[i].Character]
+	}
+	return refs, nil
+}
+
+// kanjidicCharacter is the part of a KANJIDIC2 <character> element that is imported.
+type kanjidicCharacter struct {
+	Literal  string `xml:"literal"`
+	Radicals []struct {
+		Type  string `xml:"rad_type,attr"`
+		Value int    `xml:",chardata"`
+	} `xml:"radical>rad_value"`
+	Grade        int   `xml:"misc>grade"`
+	StrokeCounts []int `xml:"misc>stroke_count"` // the first is the accepted count
+	Frequency    int   `xml:"misc>freq"`
+	JLPT         int   `xml:"misc>jlpt"` // pre-2010 levels, 4 (easiest) to 1
+	Readings     []struct {
+		Type  string `xml:"r_type,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"reading_meaning>rmgroup>reading"`
+	Meanings []struct {
+		Lang  string `xml:"m_lang,attr"` // empty for English
+		Value string `xml:",chardata"`
+	} `xml:"reading_meaning>rmgroup>meaning"`
+}
+
+// ImportKanjidic reads a KANJIDIC2 XML file, plain or gzipped, and upserts every
+// character in it. Myanmar meanings already in the collection are kept.
+// Entries are saved in batches, so when the file breaks off partway with
+// ErrMalformedKanjidic or ErrKanjidicTooLarge the result counts what was saved.
+func (s *KanjiService) ImportKanjidic(ctx context.Context, file io.Reader) (*KanjiImportResult, error) {
+	result := &KanjiImportResult{}
+	br := bufio.NewReader(file)
+	var src io.Reader = br
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return result, fmt.Errorf("%w: %v", ErrMalformedKanjidic, err)
+		}
+		defer gz.Close()
+		src = gz
+	}
+	// One byte over the limit tells a file that is too large from one that ends exactly at it.
+	limited := &io.LimitedReader{R: src, N: maxKanjidicXMLBytes + 1}
+	decodeErr := func(err error) error {
+		if limited.N <= 0 {
+			return ErrKanjidicTooLarge
+		}
+		return fmt.Errorf("%w: %v", ErrMalformedKanjidic, err)
+	}
+
+	dec := xml.NewDecoder(limited)
+	dec.Entity = xml.HTMLEntity
+	batch := make([]models.Kanji, 0, kanjiImportBatch)
+	flush := func() error {
+		inserted, updated, err := s.repo.UpsertDictionaryData(ctx, batch)
+		if err != nil {
+			return err
+		}
+		result.Inserted += inserted
+		result.Updated += updated
+		batch = batch[:0]
+		return nil
+	}
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF && limited.N > 0 {
+			break
+		}
+		if err != nil {
+			return result, decodeErr(err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "character" {
+			continue
+		}
+		var c kanjidicCharacter
+		if err := dec.DecodeElement(&c, &start); err != nil {
+			return result, decodeErr(err)
+		}
+		if !isSingleKanji(c.Literal) {
+			continue
+		}
+		batch = append(batch, c.toKanji())
+		result.Parsed++
+		if len(batch) == kanjiImportBatch {
+			if err := flush(); err != nil {
+				return result, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return result, err
+	}
+	if result.Parsed == 0 {
+		return result, ErrInvalidKanjidic
+	}
+	log.Println("[DEBUG] KANJIDIC2 import:", result.Parsed, "parsed,", result.Inserted, "inserted,", result.Updated, "updated")
+	return result, nil
+}
+
+func (c *kanjidicCharacter) toKanji() models.Kanji {
+	k := models.Kanji{
+		Character: c.Literal,
+		Meanings:  []string{},
+		Grade:     c.Grade,
+		Frequency: c.Frequency,
+		// KANJIDIC2 still uses the four pre-2010 levels; old level 2 spans today's N3
+		// and N2 and is stored as N2.
+		JLPTLevel: kanjidicJLPTLevels[c.JLPT],
+	}
+	if len(c.StrokeCounts) > 0 {
+		k.StrokeCount = c.StrokeCounts[0]
+	}
+	for _, rad := range c.Radicals {
+		if rad.Type == "classical" {
+			k.RadicalNumber = rad.Value
+			k.Radical = models.KangxiRadical(rad.Value)
+		}
+	}
+	for _, r := range c.Readings {
+		if !kanjidicReadingType[r.Type] {
+			continue
+		}
+		if r.Type == "ja_on" {
+			k.OnReadings = append(k.OnReadings, r.Value)
+		} else {
+			k.KunReadings = append(k.KunReadings, r.Value)
+		}
+	}
+	for _, m := range c.Meanings {
+		if m.Lang == "" || m.Lang == "en" {
+			k.Meanings = append(k.Meanings, m.Value)
+		}
+	}
+	return k
+}
+
+func isSingleKanji(s string) bool {
+	r, size := utf8.DecodeRuneInString(s)
+	return size > 0 && size == len(s) && unicode.Is(unicode.Han, r)
+}
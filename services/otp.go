@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	"USDT_BackEnd/db"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// otpTTL is how long an emailed one-time code stays valid.
+const otpTTL = 10 * time.Minute
+
+// maxOTPAttempts is how many wrong guesses a code survives before it is thrown away.
+const maxOTPAttempts = 5
+
+var (
+	ErrInvalidOTP          = errors.New("invalid or expired OTP")
+	ErrOTPAttemptsExceeded = errors.New("too many incorrect codes, please request a new one")
+)
+
+// generateOTP returns a uniformly random 6-digit one-time code.
+func generateOTP() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// hashOTP keys the hash with JWT_SECRET and binds it to the email, so a leaked
+// collection cannot be brute-forced offline or replayed for another account.
+func (s *UserService) hashOTP(email, otp string) string {
+	mac := hmac.New(sha256.New, []byte(s.config.JWTSecret))
+	mac.Write([]byte(email + ":" + otp))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// deleteOTPs removes any pending codes for the email from every OTP collection.
+func deleteOTPs(ctx context.Context, email string) error {
+	for _, collection := range []string{"password_otps", "email_verifications"} {
+		if _, err := db.Database.Collection(collection).DeleteMany(ctx, bson.M{"email": email}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// storeOTP replaces any pending code for the email in the given collection.
+// A reissued code keeps the wrong guesses already made, so asking for a new code
+// does not reset maxOTPAttempts. Only the hash is stored; the TTL index on
+// expiresAt removes stale records.
+func (s *UserService) storeOTP(ctx context.Context, collection, email, otp string) error {
+	_, err := db.Database.Collection(collection).UpdateOne(ctx,
+		bson.M{"email": email},
+		bson.M{
+			"$set":         bson.M{"otpHash": s.hashOTP(email, otp), "expiresAt": time.Now().Add(otpTTL)},
+			"$setOnInsert": bson.M{"attempts": 0},
+		},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// consumeOTP checks a code and deletes the record when it matches.
+// Every guess is counted in the same step that reads the record, so parallel
+// guesses cannot share one attempt; once maxOTPAttempts is reached the record is deleted.
+func (s *UserService) consumeOTP(ctx context.Context, collection, email, otp string) error {
+	coll := db.Database.Collection(collection)
+	var record struct {
+		OTPHash   string    `bson:"otpHash"`
+		Attempts  int       `bson:"attempts"`
+		ExpiresAt time.Time `bson:"expiresAt"`
+	}
+
+	err := coll.FindOneAndUpdate(ctx,
+		bson.M{"email": email, "attempts": bson.M{"$lt": maxOTPAttempts}},
+		bson.M{"$inc": bson.M{"attempts": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&record)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// Either no code is pending or its guesses are used up
+		pending, countErr := coll.CountDocuments(ctx, bson.M{"email": email})
+		if countErr == nil && pending > 0 {
+			log.Println("[DEBUG] consumeOTP: attempts exhausted in", collection, "for:", email)
+			coll.DeleteMany(ctx, bson.M{"email": email})
+			return ErrOTPAttemptsExceeded
+		}
+		log.Println("[DEBUG] consumeOTP: no pending code in", collection, "for:", email)
+		return ErrInvalidOTP
+	}
+	if err != nil {
+		log.Println("[ERROR] consumeOTP: failed to count attempt in", collection, "for:", email, "error:", err)
+		return err
+	}
+	if time.Now().After(record.ExpiresAt) {
+		log.Println("[DEBUG] consumeOTP: expired code in", collection, "for:", email)
+		coll.DeleteMany(ctx, bson.M{"email": email})
+		return ErrInvalidOTP
+	}
+
+	given := s.hashOTP(email, otp)
+	if subtle.ConstantTimeCompare([]byte(given), []byte(record.OTPHash)) != 1 {
+		if record.Attempts >= maxOTPAttempts {
+			log.Println("[DEBUG] consumeOTP: attempts exhausted in", collection, "for:", email)
+			coll.DeleteMany(ctx, bson.M{"email": email})
+			return ErrOTPAttemptsExceeded
+		}
+		return ErrInvalidOTP
+	}
+
+	coll.DeleteMany(ctx, bson.M{"email": email})
+	return nil
+}
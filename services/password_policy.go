@@ -0,0 +1,197 @@
+package services
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Password policy limits. bcrypt only looks at the first 72 bytes, and refuses longer input.
+const (
+	minPasswordLength   = 8
+	maxPasswordBytes    = 72
+	minPasswordStrength = 2 // on the 0-4 scale of estimatePasswordStrength
+)
+
+//go:embed data/common_passwords.txt
+var commonPasswordsFile string
+
+// commonPasswords is the embedded blocklist, lowercased.
+var commonPasswords = loadCommonPasswords(commonPasswordsFile)
+
+// PasswordViolation is one reason a password was rejected. Messages holds the
+// text in English ("en"), Japanese ("ja") and Myanmar ("my") so the apps can show
+// it directly; Code is stable for apps that prefer their own wording.
+type PasswordViolation struct {
+	Code     string            `json:"code"`
+	Messages map[string]string `json:"messages"`
+}
+
+// PasswordPolicyError lists every rule a password broke.
+type PasswordPolicyError struct {
+	Violations []PasswordViolation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	codes := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		codes[i] = v.Code
+	}
+	return "password does not meet the policy: " + strings.Join(codes, ", ")
+}
+
+// ValidatePassword checks a new password against the policy. email may be empty.
+// It returns a *PasswordPolicyError, or nil if the password is acceptable.
+func ValidatePassword(password, email string) error {
+	var violations []PasswordViolation
+	add := func(code, en, ja, my string) {
+		violations = append(violations, PasswordViolation{
+			Code:     code,
+			Messages: map[string]string{"en": en, "ja": ja, "my": my},
+		})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if length < minPasswordLength {
+		add("PASSWORD_TOO_SHORT",
+			fmt.Sprintf("Password must be at least %d characters.", minPasswordLength),
+			fmt.Sprintf("パスワードは%d文字以上にしてください。", minPasswordLength),
+			fmt.Sprintf("စကားဝှက်သည် အနည်းဆုံး စာလုံး %d လုံး ရှိရပါမည်။", minPasswordLength))
+	}
+	if len(password) > maxPasswordBytes {
+		add("PASSWORD_TOO_LONG",
+			fmt.Sprintf("Password must be at most %d bytes.", maxPasswordBytes),
+			fmt.Sprintf("パスワードは%dバイト以下にしてください。", maxPasswordBytes),
+			fmt.Sprintf("စကားဝှက်သည် %d bytes ထက် မပိုရပါ။", maxPasswordBytes))
+	}
+	if containsEmail(password, email) {
+		add("PASSWORD_CONTAINS_EMAIL",
+			"Password must not contain your email address.",
+			"パスワードにメールアドレスを含めないでください。",
+			"စကားဝှက်တွင် သင့်အီးမေးလ်လိပ်စာ မပါဝင်ရပါ။")
+	}
+	if isCommonPassword(password) {
+		add("PASSWORD_TOO_COMMON",
+			"This password is too common or has appeared in a data breach.",
+			"このパスワードはよく使われているか、過去に流出したことがあります。",
+			"ဤစကားဝှက်သည် အသုံးများလွန်းသည် (သို့) ယခင်က ပေါက်ကြားခဲ့ဖူးသည်။")
+	} else if length >= minPasswordLength && estimatePasswordStrength(password) < minPasswordStrength {
+		add("PASSWORD_TOO_WEAK",
+			"Password is too easy to guess. Use a longer password or mix letters, numbers and symbols.",
+			"パスワードが推測されやすすぎます。より長くするか、英字・数字・記号を組み合わせてください。",
+			"စကားဝှက်ကို ခန့်မှန်းရလွယ်လွန်းသည်။ ပိုရှည်အောင်ထားပါ (သို့) စာလုံး၊ ဂဏန်းနှင့် သင်္ကေတများ ရောထားပါ။")
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// estimatePasswordStrength scores a password from 0 (trivial) to 4 (strong) from the
+// size of its character pool and its length, after discounting repeated characters
+// and runs like "abcd" or "4321".
+func estimatePasswordStrength(password string) int {
+	var lower, upper, digit, symbol, other bool
+	for _, r := range password {
+		switch {
+		case r < unicode.MaxASCII && unicode.IsLower(r):
+			lower = true
+		case r < unicode.MaxASCII && unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		case r < unicode.MaxASCII:
+			symbol = true
+		default:
+			other = true // Japanese, Myanmar and other scripts
+		}
+	}
+	pool := 0
+	if lower {
+		pool += 26
+	}
+	if upper {
+		pool += 26
+	}
+	if digit {
+		pool += 10
+	}
+	if symbol {
+		pool += 33
+	}
+	if other {
+		pool += 100
+	}
+	if pool == 0 {
+		return 0
+	}
+
+	// Count only characters that do not continue a repeat or a +1/-1 run.
+	effective := 0
+	var prev, prevStep rune
+	for i, r := range []rune(password) {
+		step := r - prev
+		if i == 0 || (step != 0 && step != 1 && step != -1) || (i > 1 && step != prevStep) {
+			effective++
+		}
+		prev, prevStep = r, step
+	}
+
+	bits := float64(effective) * math.Log2(float64(pool))
+	switch {
+	case bits < 28:
+		return 0
+	case bits < 36:
+		return 1
+	case bits < 60:
+		return 2
+	case bits < 128:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// isCommonPassword also catches list entries with digits or symbols tacked on, e.g. "dragon2024!".
+func isCommonPassword(password string) bool {
+	p := strings.ToLower(password)
+	if commonPasswords[p] {
+		return true
+	}
+	base := strings.TrimRightFunc(p, func(r rune) bool {
+		return unicode.IsDigit(r) || unicode.IsPunct(r) || unicode.IsSymbol(r)
+	})
+	return len(base) >= 4 && commonPasswords[base]
+}
+
+// containsEmail rejects the whole address or its local part (when long enough to matter).
+func containsEmail(password, email string) bool {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return false
+	}
+	p := strings.ToLower(password)
+	if strings.Contains(p, email) {
+		return true
+	}
+	local, _, _ := strings.Cut(email, "@")
+	return len(local) >= 3 && strings.Contains(p, local)
+}
+
+func loadCommonPasswords(list string) map[string]bool {
+	set := make(map[string]bool)
+	scanner := bufio.NewScanner(strings.NewReader(list))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		set[strings.ToLower(line)] = true
+	}
+	return set
+}
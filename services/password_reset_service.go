@@ -3,80 +3,102 @@ package services
 import (
 	"context"
 	"errors"
-	"fmt"
 	"log"
-	"math/rand"
-	"time"
+	"strings"
 
-	"USDT_BackEnd/db"
+	"USDT_BackEnd/models"
 
-	"go.mongodb.org/mongo-driver/bson"
 	"golang.org/x/crypto/bcrypt"
 )
 
-func (s *UserService) SendResetOTP(ctx context.Context, email string) error {
+// SendResetOTP emails a reset code. It behaves the same whether or not the
+// email has an account (and sends mail in the background so timing does not
+// tell either), so the endpoint cannot be used to discover registered emails.
+// The only error is a *ThrottleError once the send limits are reached.
+func (s *UserService) SendResetOTP(ctx context.Context, email string, client ClientInfo) error {
 	log.Println("[DEBUG] SendResetOTP called for:", email)
+	acctLimit, ipLimit := sendKeys("reset", email, client.IP)
+	if err := s.throttle.Attempt(ctx, acctLimit, ipLimit); err != nil {
+		log.Println("[DEBUG] SendResetOTP throttled for:", email, "ip:", client.IP)
+		return err
+	}
+
 	user, err := s.repo.GetUserByEmail(ctx, email)
 	if err != nil || user == nil {
-		log.Println("[ERROR] SendResetOTP: user not found:", email)
-		return errors.New("No account found with this email")
+		log.Println("[DEBUG] SendResetOTP: user not found:", email)
+		return nil
 	}
-	if user.AuthProvider == "GOOGLE" {
-		log.Println("[ERROR] SendResetOTP: google account:", email)
-		return errors.New("This account uses Google Sign-In. Please reset your password through Google")
+	if !user.HasPassword() {
+		log.Println("[DEBUG] SendResetOTP: account has no password:", email)
+		go SendEmail(email, "Password Reset", "This account has no password. Please sign in with "+providerNames(user)+" instead, then add a password from your profile if you want one.")
+		return nil
 	}
 
-	otp := fmt.Sprintf("%06d", rand.Intn(1000000))
-	expiry := time.Now().Add(10 * time.Minute)
-	log.Println("[DEBUG] SendResetOTP: generated OTP for:", email)
-
-	db.Database.Collection("password_otps").DeleteMany(ctx, bson.M{"email": email})
-	db.Database.Collection("password_otps").InsertOne(ctx, bson.M{
-		"email":     email,
-		"otp":       otp,
-		"expiresAt": expiry,
-	})
+	otp, err := generateOTP()
+	if err != nil {
+		log.Println("[ERROR] SendResetOTP: failed to generate OTP:", err)
+		return nil
+	}
+	if err := s.storeOTP(ctx, "password_otps", email, otp); err != nil {
+		log.Println("[ERROR] SendResetOTP: failed to store OTP:", err)
+		return nil
+	}
 
-	SendEmail(email, "Password Reset OTP", "Your OTP: "+otp)
-	log.Println("[DEBUG] SendResetOTP: OTP email sent to:", email)
+	go SendEmail(email, "Password Reset OTP", "Your OTP: "+otp)
+	log.Println("[DEBUG] SendResetOTP: OTP email queued for:", email)
 	return nil
 }
 
-func (s *UserService) ResetPasswordWithOTP(ctx context.Context, email, otp, newPassword string) error {
+// ResetPasswordWithOTP sets a new password when the emailed code matches.
+// Wrong codes count against the OTP record and against the account/IP throttle.
+func (s *UserService) ResetPasswordWithOTP(ctx context.Context, email, otp, newPassword string, client ClientInfo) error {
 	log.Println("[DEBUG] ResetPasswordWithOTP called for:", email)
-	var record struct {
-		OTP       string    `bson:"otp"`
-		ExpiresAt time.Time `bson:"expiresAt"`
-	}
 
-	err := db.Database.Collection("password_otps").
-		FindOne(ctx, bson.M{"email": email, "otp": otp}).Decode(&record)
+	// Checked before the OTP so a rejected password does not use up the code.
+	if err := ValidatePassword(newPassword, email); err != nil {
+		return err
+	}
 
-	if err != nil {
-		log.Println("[ERROR] ResetPasswordWithOTP: OTP not found for:", email)
-		return errors.New("invalid or expired OTP")
+	acctLimit, ipLimit := accountKey("reset", email), ipKey("reset", client.IP)
+	if err := s.throttle.Attempt(ctx, acctLimit, ipLimit); err != nil {
+		log.Println("[DEBUG] ResetPasswordWithOTP throttled for:", email, "ip:", client.IP)
+		return err
 	}
-	if time.Now().After(record.ExpiresAt) {
-		log.Println("[ERROR] ResetPasswordWithOTP: expired OTP for:", email)
-		return errors.New("invalid or expired OTP")
+
+	if err := s.consumeOTP(ctx, "password_otps", email, otp); err != nil {
+		log.Println("[ERROR] ResetPasswordWithOTP: OTP rejected for:", email, "error:", err)
+		return err
 	}
+	s.throttle.Succeed(ctx, acctLimit)
+	s.throttle.Uncount(ctx, ipLimit)
 
 	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
 	if err != nil {
 		log.Println("[ERROR] ResetPasswordWithOTP: failed to hash password:", err)
 		return errors.New("failed to reset password")
 	}
-	_, err = db.Database.Collection("users").UpdateOne(
-		ctx,
-		bson.M{"email": email},
-		bson.M{"$set": bson.M{"password": string(hash)}},
-	)
-	if err != nil {
+	user, err := s.repo.GetUserByEmail(ctx, email)
+	if err != nil || user == nil {
+		log.Println("[ERROR] ResetPasswordWithOTP: user not found:", email)
+		return errors.New("failed to reset password")
+	}
+	if err := s.repo.UpdatePassword(ctx, user.ID, string(hash)); err != nil {
 		log.Println("[ERROR] ResetPasswordWithOTP: failed to update password:", err)
 		return errors.New("failed to reset password")
 	}
+	if err := s.invalidateAllTokens(ctx, user.ID); err != nil {
+		return errors.New("failed to reset password")
+	}
 
-	db.Database.Collection("password_otps").DeleteMany(ctx, bson.M{"email": email})
 	log.Println("[DEBUG] ResetPasswordWithOTP: password reset successful for:", email)
 	return nil
 }
+
+// providerNames lists the user's linked sign-in providers for emails, e.g. "google or apple".
+func providerNames(user *models.User) string {
+	names := make([]string, len(user.Identities))
+	for i, identity := range user.Identities {
+		names[i] = identity.Provider
+	}
+	return strings.Join(names, " or ")
+}
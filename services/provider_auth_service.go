@@ -0,0 +1,256 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"USDT_BackEnd/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrProviderAlreadyLinked = errors.New("this provider is already linked to your account")
+	ErrProviderNotLinked     = errors.New("this provider is not linked to your account")
+	ErrIdentityAlreadyLinked = errors.New("this account is already linked to another user")
+	ErrLastSignInMethod      = errors.New("cannot remove your last sign-in method, set a password first")
+	ErrPasswordAlreadySet    = errors.New("account already has a password")
+	ErrLinkRequired          = errors.New("sign in another way and link this provider from your profile first")
+)
+
+// identityProvider looks up an enabled provider by its route name.
+func (s *UserService) identityProvider(name string) (IdentityProvider, error) {
+	provider, ok := s.providers[strings.ToLower(name)]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	return provider, nil
+}
+
+// ProviderLogin signs in an existing account with an ID token from an external provider.
+func (s *UserService) ProviderLogin(ctx context.Context, providerName, idToken string, client ClientInfo) (*AuthTokens, *models.User, error) {
+	log.Println("[DEBUG] ProviderLogin service called for:", providerName)
+	provider, err := s.identityProvider(providerName)
+	if err != nil {
+		return nil, nil, err
+	}
+	identity, err := provider.Verify(ctx, idToken)
+	if err != nil {
+		log.Println("[ERROR] ProviderLogin: token validation failed:", err)
+		return nil, nil, err
+	}
+	if identity.Email == "" {
+		log.Println("[ERROR] ProviderLogin: email not found in token")
+		return nil, nil, errors.New("email not found in token")
+	}
+	log.Println("[DEBUG] ProviderLogin: email from token:", identity.Email)
+
+	user, err := s.repo.GetUserByIdentity(ctx, identity.Provider, identity.Subject)
+	if err != nil || user == nil {
+		// First sign-in with this provider for an existing account: link it by email,
+		// which is only safe for addresses a trusted provider has verified. Other
+		// providers have to be linked with LinkIdentity from a signed-in session.
+		if !identity.EmailVerified {
+			log.Println("[ERROR] ProviderLogin: email not verified for:", identity.Email)
+			return nil, nil, errors.New(provider.Name() + " email not verified")
+		}
+		user, err = s.repo.GetUserByEmail(ctx, identity.Email)
+		if err != nil || user == nil {
+			log.Println("[ERROR] ProviderLogin: account not found for:", identity.Email)
+			return nil, nil, errors.New("account does not exist, please register")
+		}
+		if !s.linkEmail[identity.Provider] {
+			log.Println("[ERROR] ProviderLogin:", identity.Provider, "may not link by email for:", identity.Email)
+			return nil, nil, ErrLinkRequired
+		}
+		if user.Identity(identity.Provider) != nil {
+			// The account already links a different account at this provider.
+			log.Println("[ERROR] ProviderLogin: subject mismatch for:", identity.Email)
+			return nil, nil, ErrIdentityAlreadyLinked
+		}
+		if err := s.addIdentity(ctx, user, identity); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	log.Println("[DEBUG] ProviderLogin: user found, starting session for:", identity.Email)
+	return s.issueTokens(ctx, user, client)
+}
+
+// ProviderRegister creates an account from an external provider's ID token.
+func (s *UserService) ProviderRegister(ctx context.Context, providerName, idToken string, client ClientInfo) (*AuthTokens, *models.User, error) {
+	log.Println("[DEBUG] ProviderRegister service called for:", providerName)
+	provider, err := s.identityProvider(providerName)
+	if err != nil {
+		return nil, nil, err
+	}
+	identity, err := provider.Verify(ctx, idToken)
+	if err != nil {
+		log.Println("[ERROR] ProviderRegister: token validation failed:", err)
+		return nil, nil, err
+	}
+	if identity.Email == "" {
+		log.Println("[ERROR] ProviderRegister: email not found in token")
+		return nil, nil, errors.New("email not found in token")
+	}
+	log.Println("[DEBUG] ProviderRegister: email from token:", identity.Email)
+
+	if !identity.EmailVerified {
+		log.Println("[ERROR] ProviderRegister: email not verified for:", identity.Email)
+		return nil, nil, errors.New(provider.Name() + " email not verified")
+	}
+
+	existing, _ := s.repo.GetUserByEmail(ctx, identity.Email)
+	if existing == nil {
+		existing, _ = s.repo.GetUserByIdentity(ctx, identity.Provider, identity.Subject)
+	}
+	if existing != nil {
+		log.Println("[ERROR] ProviderRegister: account already exists for:", identity.Email)
+		return nil, nil, errors.New("account already exists, please login")
+	}
+
+	now := time.Now()
+	user := &models.User{
+		ID:    primitive.NewObjectID(),
+		Email: identity.Email,
+		Identities: []models.UserIdentity{{
+			Provider: identity.Provider,
+			Subject:  identity.Subject,
+			Email:    identity.Email,
+			LinkedAt: now,
+		}},
+		Role: models.RoleUser,
+		// The provider already checked email_verified above
+		EmailVerified: true,
+		Subscription: models.UserSubscription{
+			SearchesLeft: s.config.DefaultSearchesLeft,
+		},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := s.repo.CreateUser(ctx, user); err != nil {
+		log.Println("[ERROR] ProviderRegister: failed to create user:", err)
+		return nil, nil, err
+	}
+
+	log.Println("[DEBUG] ProviderRegister: user created, starting session for:", identity.Email)
+	return s.issueTokens(ctx, user, client)
+}
+
+// LinkIdentity adds a provider account to the signed-in user. The user's email is
+// left unchanged; the provider's email is kept on the identity.
+func (s *UserService) LinkIdentity(ctx context.Context, userID primitive.ObjectID, providerName, idToken string) (*models.User, error) {
+	provider, err := s.identityProvider(providerName)
+	if err != nil {
+		return nil, err
+	}
+	identity, err := provider.Verify(ctx, idToken)
+	if err != nil {
+		return nil, err
+	}
+
+	currentUser, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil || currentUser == nil {
+		return nil, errors.New("user not found")
+	}
+	if currentUser.Identity(identity.Provider) != nil {
+		return nil, ErrProviderAlreadyLinked
+	}
+
+	// The provider account must not already belong to someone else
+	if owner, _ := s.repo.GetUserByIdentity(ctx, identity.Provider, identity.Subject); owner != nil {
+		return nil, ErrIdentityAlreadyLinked
+	}
+
+	if err := s.addIdentity(ctx, currentUser, identity); err != nil {
+		return nil, err
+	}
+	log.Println("[DEBUG] LinkIdentity:", identity.Provider, "linked to userID:", userID.Hex())
+	return s.repo.GetUserByID(ctx, userID)
+}
+
+// UnlinkIdentity removes a provider from the user, unless it is their last way to sign in.
+func (s *UserService) UnlinkIdentity(ctx context.Context, userID primitive.ObjectID, providerName string) (*models.User, error) {
+	providerName = strings.ToLower(providerName)
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil || user == nil {
+		return nil, errors.New("user not found")
+	}
+	if user.Identity(providerName) == nil {
+		return nil, ErrProviderNotLinked
+	}
+	if !user.HasPassword() && len(user.Identities) == 1 {
+		return nil, ErrLastSignInMethod
+	}
+
+	// The repository re-checks the guard atomically in case of a concurrent unlink.
+	removed, err := s.repo.RemoveIdentity(ctx, userID, providerName)
+	if err != nil {
+		return nil, err
+	}
+	if !removed {
+		return nil, ErrLastSignInMethod
+	}
+	log.Println("[DEBUG] UnlinkIdentity:", providerName, "unlinked from userID:", userID.Hex())
+	return s.repo.GetUserByID(ctx, userID)
+}
+
+func (s *UserService) addIdentity(ctx context.Context, user *models.User, identity *ExternalIdentity) error {
+	linked := models.UserIdentity{
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+		LinkedAt: time.Now(),
+	}
+	added, err := s.repo.AddIdentity(ctx, user.ID, linked)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrIdentityAlreadyLinked
+		}
+		log.Println("[ERROR] Failed to link identity for userID:", user.ID.Hex(), "error:", err)
+		return err
+	}
+	if !added {
+		return ErrProviderAlreadyLinked
+	}
+	user.Identities = append(user.Identities, linked)
+	return nil
+}
+
+// AddPassword lets a user who signed up through a provider also sign in with email and password.
+func (s *UserService) AddPassword(ctx context.Context, userID primitive.ObjectID, password string) error {
+	if password == "" {
+		return errors.New("password is required")
+	}
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil || user == nil {
+		return errors.New("user not found")
+	}
+	if user.HasPassword() {
+		return ErrPasswordAlreadySet
+	}
+	if err := ValidatePassword(password, user.Email); err != nil {
+		return err
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		log.Println("[ERROR] Failed to hash password:", err)
+		return errors.New("failed to process password")
+	}
+	set, err := s.repo.SetPasswordIfMissing(ctx, userID, string(hashed))
+	if err != nil {
+		log.Println("[ERROR] AddPassword failed for userID:", userID.Hex(), "error:", err)
+		return err
+	}
+	if !set {
+		return ErrPasswordAlreadySet
+	}
+	log.Println("[DEBUG] Password added for userID:", userID.Hex())
+	return nil
+}
@@ -0,0 +1,250 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"time"
+
+	"USDT_BackEnd/models"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("INVALID_REFRESH_TOKEN")
+	ErrRefreshTokenReused  = errors.New("REFRESH_TOKEN_REUSED")
+	ErrSessionNotFound     = errors.New("session not found")
+)
+
+// Token types carried in the "typ" claim.
+const (
+	AccessTokenType    = "access"
+	challengeTokenType = "2fa_challenge"
+)
+
+// challengeTTL is how long a user has to enter their second factor after the password.
+const challengeTTL = 5 * time.Minute
+
+// ClientInfo describes the device a request came from.
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// AuthTokens is what a successful sign-in or refresh hands back to the client.
+// When the user has two-factor authentication enabled, sign-in only returns a
+// ChallengeToken, which is exchanged for real tokens by VerifyTwoFactorLogin.
+type AuthTokens struct {
+	AccessToken    string
+	RefreshToken   string
+	ChallengeToken string
+	ExpiresIn      int64 // lifetime in seconds of the access (or challenge) token
+}
+
+// issueTokens starts a new session family for the user and returns the first token pair,
+// or a challenge token if the user still has to pass the second factor.
+func (s *UserService) issueTokens(ctx context.Context, user *models.User, client ClientInfo) (*AuthTokens, *models.User, error) {
+	if user == nil {
+		return nil, nil, errors.New("user is nil")
+	}
+	if user.TwoFactor.Enabled {
+		challenge, err := s.signChallengeToken(user)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &AuthTokens{ChallengeToken: challenge, ExpiresIn: int64(challengeTTL.Seconds())}, user, nil
+	}
+	now := time.Now()
+	tokens, err := s.createSession(ctx, user, primitive.NewObjectID(), now, client)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.cancelScheduledDeletion(ctx, user)
+	return tokens, user, nil
+}
+
+// createSession stores a fresh refresh token in the given family and signs an access token for it.
+func (s *UserService) createSession(ctx context.Context, user *models.User, familyID primitive.ObjectID, signedInAt time.Time, client ClientInfo) (*AuthTokens, error) {
+	refreshToken, err := generateRefreshToken()
+	if err != nil {
+		log.Println("[ERROR] Failed to generate refresh token:", err)
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &models.Session{
+		UserID:     user.ID,
+		FamilyID:   familyID,
+		TokenHash:  hashRefreshToken(refreshToken),
+		UserAgent:  client.UserAgent,
+		IP:         client.IP,
+		SignedInAt: signedInAt,
+		CreatedAt:  now,
+		ExpiresAt:  now.AddDate(0, 0, s.config.RefreshTokenDays),
+	}
+	if err := s.sessions.Create(ctx, session); err != nil {
+		log.Println("[ERROR] Failed to store session:", err)
+		return nil, err
+	}
+
+	accessToken, expiresIn, err := s.signAccessToken(user, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthTokens{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    expiresIn,
+	}, nil
+}
+
+func (s *UserService) signAccessToken(user *models.User, familyID primitive.ObjectID) (string, int64, error) {
+	ttl := time.Duration(s.config.AccessTokenMinutes) * time.Minute
+	now := time.Now()
+	tokenClaims := jwt.MapClaims{
+		"typ":     AccessTokenType,
+		"user_id": user.ID.Hex(),
+		"role":    user.Role,
+		"sid":     familyID.Hex(),
+		"ver":     user.TokenVersion,
+		"iat":     now.Unix(),
+		"exp":     now.Add(ttl).Unix(),
+	}
+
+	tokenString, err := s.keys.Sign(tokenClaims)
+	if err != nil {
+		log.Println("[ERROR] Failed to sign JWT:", err)
+		return "", 0, err
+	}
+	return tokenString, int64(ttl.Seconds()), nil
+}
+
+// signChallengeToken proves the password step passed. It has no sid, so
+// AuthMiddleware never accepts it as an access token.
+func (s *UserService) signChallengeToken(user *models.User) (string, error) {
+	now := time.Now()
+	token, err := s.keys.Sign(jwt.MapClaims{
+		"typ":     challengeTokenType,
+		"user_id": user.ID.Hex(),
+		"ver":     user.TokenVersion,
+		"iat":     now.Unix(),
+		"exp":     now.Add(challengeTTL).Unix(),
+	})
+	if err != nil {
+		log.Println("[ERROR] Failed to sign 2FA challenge:", err)
+		return "", err
+	}
+	return token, nil
+}
+
+// RefreshSession exchanges a refresh token for a new token pair.
+// Presenting a token that was already rotated means it leaked, so the whole family is revoked.
+func (s *UserService) RefreshSession(ctx context.Context, refreshToken string, client ClientInfo) (*AuthTokens, error) {
+	if refreshToken == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+	tokenHash := hashRefreshToken(refreshToken)
+
+	current, err := s.sessions.Rotate(ctx, tokenHash)
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			log.Println("[ERROR] RefreshSession: rotate failed:", err)
+			return nil, err
+		}
+		previous, lookupErr := s.sessions.GetByTokenHash(ctx, tokenHash)
+		if lookupErr == nil && previous.RotatedAt != nil && previous.RevokedAt == nil {
+			log.Println("[WARN] RefreshSession: refresh token reuse detected, revoking family:", previous.FamilyID.Hex())
+			if err := s.sessions.RevokeFamily(ctx, previous.FamilyID); err != nil {
+				log.Println("[ERROR] RefreshSession: failed to revoke family:", err)
+			}
+			return nil, ErrRefreshTokenReused
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+
+	user, err := s.repo.GetUserByID(ctx, current.UserID)
+	if err != nil || user == nil {
+		log.Println("[ERROR] RefreshSession: user not found for session:", current.FamilyID.Hex())
+		_ = s.sessions.RevokeFamily(ctx, current.FamilyID)
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if client.UserAgent == "" {
+		client.UserAgent = current.UserAgent
+	}
+	return s.createSession(ctx, user, current.FamilyID, current.SignedInAt, client)
+}
+
+// Logout revokes the sign-in the refresh token belongs to.
+func (s *UserService) Logout(ctx context.Context, refreshToken string) error {
+	if refreshToken == "" {
+		return ErrInvalidRefreshToken
+	}
+	session, err := s.sessions.GetByTokenHash(ctx, hashRefreshToken(refreshToken))
+	if err != nil {
+		return ErrInvalidRefreshToken
+	}
+	return s.sessions.RevokeFamily(ctx, session.FamilyID)
+}
+
+// ListSessions returns the user's active sign-ins.
+func (s *UserService) ListSessions(ctx context.Context, userID primitive.ObjectID) ([]models.Session, error) {
+	return s.sessions.ListActiveForUser(ctx, userID)
+}
+
+// RevokeSession signs out one of the user's devices.
+func (s *UserService) RevokeSession(ctx context.Context, userID primitive.ObjectID, sessionIDStr string) error {
+	familyID, err := primitive.ObjectIDFromHex(sessionIDStr)
+	if err != nil {
+		return ErrSessionNotFound
+	}
+	found, err := s.sessions.RevokeUserFamily(ctx, userID, familyID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// RevokeOtherSessions signs out every device except the current one (if given).
+func (s *UserService) RevokeOtherSessions(ctx context.Context, userID primitive.ObjectID, currentSessionID string) (int64, error) {
+	keep, _ := primitive.ObjectIDFromHex(currentSessionID)
+	return s.sessions.RevokeAllForUser(ctx, userID, keep)
+}
+
+// invalidateAllTokens logs the user out everywhere: access tokens fail the version
+// check and refresh tokens can no longer be exchanged.
+func (s *UserService) invalidateAllTokens(ctx context.Context, userID primitive.ObjectID) error {
+	if err := s.repo.IncrementTokenVersion(ctx, userID); err != nil {
+		log.Println("[ERROR] Failed to bump token version for userID:", userID.Hex(), "error:", err)
+		return err
+	}
+	if _, err := s.sessions.RevokeAllForUser(ctx, userID, primitive.NilObjectID); err != nil {
+		log.Println("[ERROR] Failed to revoke sessions for userID:", userID.Hex(), "error:", err)
+		return err
+	}
+	return nil
+}
+
+func generateRefreshToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
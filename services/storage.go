@@ -3,7 +3,9 @@ package services
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"mime/multipart"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
@@ -60,6 +62,48 @@ func (s *StorageService) UploadFile(file multipart.File, fileName string) (strin
 	return url, nil
 }
 
+// UploadPrivateFile stores data that must not be publicly readable; share it with PresignedURL.
+func (s *StorageService) UploadPrivateFile(fileName string, data []byte, contentType string) error {
+	_, err := s.client.PutObject(&s3.PutObjectInput{
+		Bucket:      aws.String(s.bucketName),
+		Key:         aws.String(fileName),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String(contentType),
+		ACL:         aws.String("private"),
+	})
+	return err
+}
+
+// PresignedURL returns a download link for a private file that stops working after ttl.
+func (s *StorageService) PresignedURL(fileName string, ttl time.Duration) (string, error) {
+	req, _ := s.client.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(fileName),
+	})
+	return req.Presign(ttl)
+}
+
+// GetFile downloads a file, refusing ones larger than maxBytes.
+func (s *StorageService) GetFile(fileName string, maxBytes int64) ([]byte, time.Time, error) {
+	out, err := s.client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(fileName),
+	})
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer out.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(out.Body, maxBytes+1))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, time.Time{}, fmt.Errorf("file %s is larger than %d bytes", fileName, maxBytes)
+	}
+	return data, aws.TimeValue(out.LastModified), nil
+}
+
 func (s *StorageService) DeleteFile(fileName string) error {
 	_, err := s.client.DeleteObject(&s3.DeleteObjectInput{
 		Bucket: aws.String(s.bucketName),
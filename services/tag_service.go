@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"USDT_BackEnd/models"
+	"USDT_BackEnd/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrTagNotFound    = errors.New("tag not found")
+	ErrTagExists      = errors.New("a tag with this name already exists")
+	ErrInvalidTagName = errors.New("tag name must be 1-40 lowercase letters, digits or dashes")
+)
+
+var tagNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,39}$`)
+
+type TagService struct {
+	repo  *repository.TagRepository
+	words *repository.WordRepository
+}
+
+func NewTagService() *TagService {
+	return &TagService{
+		repo:  &repository.TagRepository{},
+		words: &repository.WordRepository{},
+	}
+}
+
+// ListTags returns every tag with the number of words using it.
+func (s *TagService) ListTags(ctx context.Context) ([]models.Tag, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *TagService) CreateTag(ctx context.Context, name, description string) (*models.Tag, error) {
+	name, err := normalizeTagName(name)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	tag := &models.Tag{
+		Name:        name,
+		Description: strings.TrimSpace(description),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.repo.Create(ctx, tag); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrTagExists
+		}
+		return nil, err
+	}
+	log.Println("[DEBUG] Tag created:", name)
+	return tag, nil
+}
+
+// UpdateTag changes a tag's name and description and returns it before and after.
+// A new name is applied to every word that had the old one.
+func (s *TagService) UpdateTag(ctx context.Context, idStr, name, description string) (before, after *models.Tag, err error) {
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return nil, nil, ErrTagNotFound
+	}
+	name, err = normalizeTagName(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	before, err = s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, ErrTagNotFound
+	}
+
+	updated := *before
+	updated.Name = name
+	updated.Description = strings.TrimSpace(description)
+	found, err := s.repo.Update(ctx, &updated)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, nil, ErrTagExists
+		}
+		return nil, nil, err
+	}
+	if !found {
+		return nil, nil, ErrTagNotFound
+	}
+
+	if name != before.Name {
+		renamed, err := s.words.RenameTag(ctx, before.Name, name)
+		if err != nil {
+			log.Println("[ERROR] Failed to rename tag on words:", before.Name, "->", name, "error:", err)
+			return nil, nil, err
+		}
+		log.Println("[DEBUG] Tag renamed:", before.Name, "->", name, "on", renamed, "words")
+	}
+	return before, &updated, nil
+}
+
+// DeleteTag removes a tag and takes it off every word, returning the deleted tag.
+func (s *TagService) DeleteTag(ctx context.Context, idStr string) (*models.Tag, error) {
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return nil, ErrTagNotFound
+	}
+	tag, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrTagNotFound
+	}
+	deleted, err := s.repo.Delete(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !deleted {
+		return nil, ErrTagNotFound
+	}
+
+	removed, err := s.words.RemoveTag(ctx, tag.Name)
+	if err != nil {
+		log.Println("[ERROR] Failed to remove deleted tag from words:", tag.Name, "error:", err)
+		return nil, err
+	}
+	log.Println("[DEBUG] Tag deleted:", tag.Name, "removed from", removed, "words")
+	return tag, nil
+}
+
+func normalizeTagName(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !tagNamePattern.MatchString(name) {
+		return "", ErrInvalidTagName
+	}
+	return name, nil
+}
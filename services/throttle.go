@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"USDT_BackEnd/repository"
+)
+
+// throttlePolicy decides when repeated failures start locking a key out.
+// After freeAttempts failures each further failure doubles the lockout,
+// starting at baseLockout and never exceeding maxLockout.
+type throttlePolicy struct {
+	freeAttempts int
+	baseLockout  time.Duration
+	maxLockout   time.Duration
+}
+
+var (
+	accountThrottle = throttlePolicy{freeAttempts: 5, baseLockout: 30 * time.Second, maxLockout: time.Hour}
+	ipThrottle      = throttlePolicy{freeAttempts: 20, baseLockout: 30 * time.Second, maxLockout: time.Hour}
+
+	// Emailing a code is limited separately, so an address cannot be flooded
+	// and a code's guesses cannot be renewed by asking for another one.
+	sendAccountThrottle = throttlePolicy{freeAttempts: 3, baseLockout: time.Minute, maxLockout: time.Hour}
+	sendIPThrottle      = throttlePolicy{freeAttempts: 20, baseLockout: time.Minute, maxLockout: time.Hour}
+)
+
+// failureMemory is how long an attempt counter lives after the last attempt.
+const failureMemory = 24 * time.Hour
+
+// ThrottleError is returned while a key is locked out.
+type ThrottleError struct {
+	RetryAfter time.Duration
+}
+
+func (e *ThrottleError) Error() string {
+	return fmt.Sprintf("too many attempts, try again in %d seconds", e.RetryAfterSeconds())
+}
+
+// RetryAfterSeconds rounds the remaining lockout up to whole seconds.
+func (e *ThrottleError) RetryAfterSeconds() int {
+	return int((e.RetryAfter + time.Second - 1) / time.Second)
+}
+
+// throttleKey identifies one counter; build it with accountKey or ipKey.
+type throttleKey struct {
+	id     string
+	policy throttlePolicy
+}
+
+func accountKey(scope, email string) throttleKey {
+	return throttleKey{id: scope + ":acct:" + strings.ToLower(strings.TrimSpace(email)), policy: accountThrottle}
+}
+
+func ipKey(scope, ip string) throttleKey {
+	return throttleKey{id: scope + ":ip:" + ip, policy: ipThrottle}
+}
+
+// sendKeys limits how often a code is emailed, per address and per IP.
+func sendKeys(scope, email, ip string) (throttleKey, throttleKey) {
+	acct, addr := accountKey(scope+"-send", email), ipKey(scope+"-send", ip)
+	acct.policy, addr.policy = sendAccountThrottle, sendIPThrottle
+	return acct, addr
+}
+
+// Throttle tracks attempts per account and per IP in the login_attempts collection.
+type Throttle struct {
+	repo *repository.LoginAttemptRepository
+}
+
+func NewThrottle() *Throttle {
+	return &Throttle{repo: &repository.LoginAttemptRepository{}}
+}
+
+// ForgetAccount drops the per-account counters of every scope, e.g. when the account is deleted.
+func (t *Throttle) ForgetAccount(ctx context.Context, email string) error {
+	return t.repo.DeleteForAccount(ctx, email)
+}
+
+// Attempt counts an attempt against every key before the caller checks the
+// credentials, and returns a *ThrottleError without counting anything if any key
+// is locked. A correct guess is cleared with Succeed or taken back with Uncount.
+func (t *Throttle) Attempt(ctx context.Context, keys ...throttleKey) error {
+	for i, k := range keys {
+		attempt, err := t.repo.CountAttempt(ctx, k.id, k.policy.freeAttempts, k.policy.baseLockout, k.policy.maxLockout, failureMemory)
+		if err != nil {
+			log.Println("[ERROR] Throttle: failed to count attempt for", k.id, "error:", err)
+			continue
+		}
+		if attempt != nil {
+			if attempt.Failures > k.policy.freeAttempts {
+				log.Println("[DEBUG] Throttle: locking", k.id, "until", attempt.LockedUntil, "after", attempt.Failures, "attempts")
+			}
+			continue
+		}
+		// Locked: the keys counted so far did not get a guess either
+		t.Uncount(ctx, keys[:i]...)
+		return &ThrottleError{RetryAfter: t.lockedFor(ctx, k)}
+	}
+	return nil
+}
+
+// lockedFor returns how long k stays locked, at least one second.
+func (t *Throttle) lockedFor(ctx context.Context, k throttleKey) time.Duration {
+	attempt, err := t.repo.Get(ctx, k.id)
+	if err != nil {
+		log.Println("[ERROR] Throttle: lookup failed for", k.id, "error:", err)
+	}
+	if attempt == nil || time.Until(attempt.LockedUntil) < time.Second {
+		return time.Second
+	}
+	return time.Until(attempt.LockedUntil)
+}
+
+// Uncount takes back the attempt counted against every key, e.g. the IP counter
+// after a correct password, so successful sign-ins do not lock out a shared address.
+func (t *Throttle) Uncount(ctx context.Context, keys ...throttleKey) {
+	for _, k := range keys {
+		if err := t.repo.UncountAttempt(ctx, k.id); err != nil {
+			log.Println("[ERROR] Throttle: failed to uncount attempt for", k.id, "error:", err)
+		}
+	}
+}
+
+// Succeed clears the counters, e.g. the account counter after a correct password.
+func (t *Throttle) Succeed(ctx context.Context, keys ...throttleKey) {
+	for _, k := range keys {
+		if err := t.repo.Reset(ctx, k.id); err != nil {
+			log.Println("[ERROR] Throttle: failed to reset", k.id, "error:", err)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package services
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"USDT_BackEnd/config"
+	"USDT_BackEnd/models"
+	"USDT_BackEnd/repository"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const signingAlgorithm = "EdDSA"
+
+// A token signed with an unknown kid reloads the keyset at most this often, or
+// sooner after a failed reload.
+const (
+	keyReloadInterval = 30 * time.Second
+	keyReloadRetry    = 5 * time.Second
+)
+
+// JWK is the public half of a signing key in JSON Web Key format.
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+}
+
+// KeyManager signs and verifies JWTs with a rotating set of Ed25519 keys stored in MongoDB.
+// Every instance reloads the set periodically, so keys created by one instance are
+// picked up by the others.
+type KeyManager struct {
+	config *config.Config
+	repo   *repository.SigningKeyRepository
+
+	mu         sync.RWMutex
+	signingKID string
+	signingKey ed25519.PrivateKey
+	publicKeys map[string]ed25519.PublicKey
+	jwks       []JWK
+
+	reloadMu   sync.Mutex // one unknown-kid reload at a time
+	nextReload time.Time
+}
+
+func NewKeyManager(cfg *config.Config) *KeyManager {
+	m := &KeyManager{
+		config: cfg,
+		repo:   &repository.SigningKeyRepository{},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := m.rotateIfDue(ctx); err != nil {
+		log.Fatal("❌ Cannot load JWT signing keys:", err)
+	}
+	return m
+}
+
+// StartRotation reloads the keyset every few minutes and creates a new signing key
+// once the current one is older than JWT_KEY_ROTATION_DAYS.
+func (m *KeyManager) StartRotation() {
+	go func() {
+		ticker := time.NewTicker(10 * time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			if err := m.rotateIfDue(ctx); err != nil {
+				log.Println("[ERROR] KeyManager: rotation check failed:", err)
+			}
+			cancel()
+		}
+	}()
+}
+
+// Sign signs claims with the current key and sets the kid header.
+func (m *KeyManager) Sign(claims jwt.MapClaims) (string, error) {
+	m.mu.RLock()
+	kid, key := m.signingKID, m.signingKey
+	m.mu.RUnlock()
+	if key == nil {
+		return "", errors.New("no signing key loaded")
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["kid"] = kid
+	return token.SignedString(key)
+}
+
+// Parse verifies a token against any key that has not been retired yet.
+func (m *KeyManager) Parse(tokenStr string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := m.publicKey(kid)
+		if !ok {
+			key, ok = m.reloadForKID(kid)
+		}
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		return key, nil
+	}, jwt.WithValidMethods([]string{signingAlgorithm}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (m *KeyManager) publicKey(kid string) (ed25519.PublicKey, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	key, ok := m.publicKeys[kid]
+	return key, ok
+}
+
+// reloadForKID reloads the keyset for a kid this instance does not know, so a key
+// another instance has just rotated in is accepted before the periodic reload.
+// Concurrent callers wait for the one reload and then look again.
+func (m *KeyManager) reloadForKID(kid string) (ed25519.PublicKey, bool) {
+	m.reloadMu.Lock()
+	defer m.reloadMu.Unlock()
+
+	if key, ok := m.publicKey(kid); ok {
+		return key, true
+	}
+	if time.Now().Before(m.nextReload) {
+		return nil, false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	keys, err := m.repo.ListUsable(ctx)
+	if err == nil {
+		err = m.load(keys)
+	}
+	if err != nil {
+		m.nextReload = time.Now().Add(keyReloadRetry)
+		log.Println("[ERROR] KeyManager: reload for unknown kid failed:", err)
+		return nil, false
+	}
+	m.nextReload = time.Now().Add(keyReloadInterval)
+	return m.publicKey(kid)
+}
+
+// JWKS returns the public keys that currently verify tokens.
+func (m *KeyManager) JWKS() []JWK {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]JWK, len(m.jwks))
+	copy(out, m.jwks)
+	return out
+}
+
+// rotateIfDue creates a new signing key when the newest one is retired or older
+// than JWT_KEY_ROTATION_DAYS, then reloads the keyset. Instances that rotate at the
+// same moment all try to store the successor of the same key; only one is stored,
+// and every instance keeps that one and retires the rest.
+func (m *KeyManager) rotateIfDue(ctx context.Context) error {
+	newest, err := m.repo.Newest(ctx)
+	if err != nil {
+		return err
+	}
+
+	rotateAfter := time.Duration(m.config.JWTKeyRotationDays) * 24 * time.Hour
+	if newest == nil || newest.RetiresAt != nil || time.Since(newest.CreatedAt) >= rotateAfter {
+		rotatesFrom := ""
+		if newest != nil {
+			rotatesFrom = newest.KID
+		}
+		created, err := m.createKey(ctx, rotatesFrom)
+		if err != nil {
+			return err
+		}
+		// Old keys keep verifying until every token they signed has expired.
+		grace := time.Duration(m.config.AccessTokenMinutes)*time.Minute + time.Hour
+		if err := m.repo.RetireAllExcept(ctx, created.ID, time.Now().Add(grace)); err != nil {
+			return err
+		}
+		log.Println("🔑 Rotated JWT signing key, new kid:", created.KID)
+	}
+
+	keys, err := m.repo.ListUsable(ctx)
+	if err != nil {
+		return err
+	}
+	return m.load(keys)
+}
+
+// createKey generates a key to follow rotatesFrom and returns the stored successor,
+// which is another instance's key if that one got there first.
+func (m *KeyManager) createKey(ctx context.Context, rotatesFrom string) (*models.SigningKey, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := sealSecret(m.config.JWTSecret, priv.Seed())
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(pub)
+	return m.repo.InsertRotation(ctx, &models.SigningKey{
+		KID:         base64.RawURLEncoding.EncodeToString(sum[:12]),
+		Algorithm:   signingAlgorithm,
+		PublicKey:   pub,
+		PrivateKey:  sealed,
+		CreatedAt:   time.Now(),
+		RotatesFrom: rotatesFrom,
+	})
+}
+
+func (m *KeyManager) load(keys []models.SigningKey) error {
+	current := activeKey(keys)
+	if current == nil {
+		return errors.New("no active signing key")
+	}
+	seed, err := openSecret(m.config.JWTSecret, current.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("cannot decrypt signing key %s (was JWT_SECRET changed?): %w", current.KID, err)
+	}
+	if len(seed) != ed25519.SeedSize {
+		return fmt.Errorf("signing key %s has an invalid seed", current.KID)
+	}
+
+	publicKeys := make(map[string]ed25519.PublicKey, len(keys))
+	jwks := make([]JWK, 0, len(keys))
+	for _, k := range keys {
+		publicKeys[k.KID] = ed25519.PublicKey(k.PublicKey)
+		jwks = append(jwks, JWK{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(k.PublicKey),
+			Kid: k.KID,
+			Alg: signingAlgorithm,
+			Use: "sig",
+		})
+	}
+
+	m.mu.Lock()
+	m.signingKID = current.KID
+	m.signingKey = ed25519.NewKeyFromSeed(seed)
+	m.publicKeys = publicKeys
+	m.jwks = jwks
+	m.mu.Unlock()
+	return nil
+}
+
+// activeKey picks the newest key that has not been scheduled for retirement.
+// keys must be sorted newest first.
+func activeKey(keys []models.SigningKey) *models.SigningKey {
+	for i := range keys {
+		if keys[i].RetiresAt == nil {
+			return &keys[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,293 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	"USDT_BackEnd/models"
+	"USDT_BackEnd/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// recoveryCodeCount is how many one-time recovery codes are handed out at a time.
+const recoveryCodeCount = 10
+
+var (
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorSetupMissing   = errors.New("start two-factor setup first")
+	ErrInvalidTwoFactorCode    = errors.New("invalid authentication code")
+	ErrInvalidChallenge        = errors.New("invalid or expired sign-in challenge")
+	ErrTwoFactorRequired       = errors.New("two-factor authentication is required for admin accounts")
+)
+
+// TwoFactorSetup is what an authenticator app needs to enroll the account.
+type TwoFactorSetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauthUri"`
+}
+
+// SetupTwoFactor creates a new TOTP secret for the user. It only takes effect
+// once EnableTwoFactor confirms a code generated from it.
+func (s *UserService) SetupTwoFactor(ctx context.Context, userID primitive.ObjectID) (*TwoFactorSetup, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil || user == nil {
+		return nil, errors.New("user not found")
+	}
+	if user.TwoFactor.Enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		log.Println("[ERROR] SetupTwoFactor: failed to generate secret:", err)
+		return nil, err
+	}
+	sealed, err := sealSecret(s.config.JWTSecret, []byte(secret))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetPendingTwoFactorSecret(ctx, userID, sealed); err != nil {
+		log.Println("[ERROR] SetupTwoFactor: failed to store secret:", err)
+		return nil, err
+	}
+
+	return &TwoFactorSetup{
+		Secret: secret,
+		URI:    utils.TOTPURI(s.config.TOTPIssuer, user.Email, secret),
+	}, nil
+}
+
+// EnableTwoFactor confirms the pending secret with a code from the authenticator app
+// and returns the recovery codes. They are only shown this once.
+func (s *UserService) EnableTwoFactor(ctx context.Context, userID primitive.ObjectID, code string) ([]string, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil || user == nil {
+		return nil, errors.New("user not found")
+	}
+	if user.TwoFactor.Enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if user.TwoFactor.PendingSecret == "" {
+		return nil, ErrTwoFactorSetupMissing
+	}
+
+	secret, err := openSecret(s.config.JWTSecret, user.TwoFactor.PendingSecret)
+	if err != nil {
+		log.Println("[ERROR] EnableTwoFactor: cannot decrypt pending secret for:", userID.Hex(), "error:", err)
+		return nil, err
+	}
+	step, ok := utils.VerifyTOTP(string(secret), code, time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.EnableTwoFactor(ctx, userID, user.TwoFactor.PendingSecret, hashes, step); err != nil {
+		log.Println("[ERROR] EnableTwoFactor: failed to save:", err)
+		return nil, err
+	}
+
+	log.Println("[DEBUG] Two-factor enabled for userID:", userID.Hex())
+	return codes, nil
+}
+
+// DisableTwoFactor turns 2FA off after checking a current code or a recovery code.
+// Admins cannot turn it off while the deployment requires it.
+func (s *UserService) DisableTwoFactor(ctx context.Context, userID primitive.ObjectID, code, recoveryCode string) error {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil || user == nil {
+		return errors.New("user not found")
+	}
+	if !user.TwoFactor.Enabled {
+		return ErrTwoFactorNotEnabled
+	}
+	if user.Role == models.RoleAdmin {
+		settings, err := s.settings.Get(ctx)
+		if err != nil {
+			return err
+		}
+		if settings.RequireAdminTwoFactor {
+			return ErrTwoFactorRequired
+		}
+	}
+	if err := s.verifySecondFactor(ctx, user, code, recoveryCode); err != nil {
+		return err
+	}
+
+	if err := s.repo.DisableTwoFactor(ctx, userID); err != nil {
+		log.Println("[ERROR] DisableTwoFactor: failed to save:", err)
+		return err
+	}
+	log.Println("[DEBUG] Two-factor disabled for userID:", userID.Hex())
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces every recovery code after checking a current TOTP code.
+func (s *UserService) RegenerateRecoveryCodes(ctx context.Context, userID primitive.ObjectID, code string) ([]string, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil || user == nil {
+		return nil, errors.New("user not found")
+	}
+	if !user.TwoFactor.Enabled {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if err := s.verifySecondFactor(ctx, user, code, ""); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetRecoveryCodes(ctx, userID, hashes); err != nil {
+		log.Println("[ERROR] RegenerateRecoveryCodes: failed to save:", err)
+		return nil, err
+	}
+	return codes, nil
+}
+
+// VerifyTwoFactorLogin completes a sign-in started by Login or a provider login.
+// Either a TOTP code or one of the recovery codes is accepted.
+func (s *UserService) VerifyTwoFactorLogin(ctx context.Context, challengeToken, code, recoveryCode string, client ClientInfo) (*AuthTokens, *models.User, error) {
+	claims, err := s.keys.Parse(challengeToken)
+	if err != nil {
+		return nil, nil, ErrInvalidChallenge
+	}
+	if typ, _ := claims["typ"].(string); typ != challengeTokenType {
+		return nil, nil, ErrInvalidChallenge
+	}
+	userIDStr, _ := claims["user_id"].(string)
+	userID, err := primitive.ObjectIDFromHex(userIDStr)
+	if err != nil {
+		return nil, nil, ErrInvalidChallenge
+	}
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil || user == nil || !user.TwoFactor.Enabled {
+		return nil, nil, ErrInvalidChallenge
+	}
+	// A password change or reset since the challenge was issued voids it.
+	if version, _ := claims["ver"].(float64); int(version) != user.TokenVersion {
+		return nil, nil, ErrInvalidChallenge
+	}
+
+	acctLimit, ipLimit := accountKey("2fa", user.Email), ipKey("2fa", client.IP)
+	if err := s.throttle.Attempt(ctx, acctLimit, ipLimit); err != nil {
+		log.Println("[DEBUG] 2FA verification throttled for:", user.Email, "ip:", client.IP)
+		return nil, nil, err
+	}
+	if err := s.verifySecondFactor(ctx, user, code, recoveryCode); err != nil {
+		if !errors.Is(err, ErrInvalidTwoFactorCode) {
+			// Not a wrong guess, so it does not count against the limits
+			s.throttle.Uncount(ctx, acctLimit, ipLimit)
+		}
+		return nil, nil, err
+	}
+	s.throttle.Succeed(ctx, acctLimit)
+	s.throttle.Uncount(ctx, ipLimit)
+
+	tokens, err := s.createSession(ctx, user, primitive.NewObjectID(), time.Now(), client)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.cancelScheduledDeletion(ctx, user)
+	log.Println("[DEBUG] 2FA sign-in successful for:", user.Email)
+	return tokens, user, nil
+}
+
+// GetAppSettings returns the deployment-wide settings.
+func (s *UserService) GetAppSettings(ctx context.Context) (*models.AppSettings, error) {
+	return s.settings.Get(ctx)
+}
+
+// SetRequireAdminTwoFactor switches the 2FA requirement for admin accounts.
+// The admin turning it on must already use 2FA, so they cannot lock themselves out.
+func (s *UserService) SetRequireAdminTwoFactor(ctx context.Context, actorID primitive.ObjectID, required bool) error {
+	if required {
+		actor, err := s.repo.GetUserByID(ctx, actorID)
+		if err != nil || actor == nil {
+			return errors.New("user not found")
+		}
+		if !actor.TwoFactor.Enabled {
+			return ErrTwoFactorNotEnabled
+		}
+	}
+	log.Println("[DEBUG] RequireAdminTwoFactor set to", required, "by:", actorID.Hex())
+	return s.settings.SetRequireAdminTwoFactor(ctx, required)
+}
+
+// verifySecondFactor checks a TOTP code (each time step is accepted once) or,
+// when no code is given, consumes a recovery code.
+func (s *UserService) verifySecondFactor(ctx context.Context, user *models.User, code, recoveryCode string) error {
+	if strings.TrimSpace(code) != "" {
+		secret, err := openSecret(s.config.JWTSecret, user.TwoFactor.Secret)
+		if err != nil {
+			log.Println("[ERROR] Cannot decrypt 2FA secret for:", user.ID.Hex(), "error:", err)
+			return err
+		}
+		step, ok := utils.VerifyTOTP(string(secret), code, time.Now())
+		if !ok {
+			return ErrInvalidTwoFactorCode
+		}
+		claimed, err := s.repo.ClaimTOTPStep(ctx, user.ID, step)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			log.Println("[DEBUG] Replayed TOTP code for:", user.ID.Hex())
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	if strings.TrimSpace(recoveryCode) == "" {
+		return ErrInvalidTwoFactorCode
+	}
+	consumed, err := s.repo.ConsumeRecoveryCode(ctx, user.ID, hashRecoveryCode(recoveryCode))
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidTwoFactorCode
+	}
+	log.Println("[DEBUG] Recovery code used for:", user.ID.Hex())
+	return nil
+}
+
+// generateRecoveryCodes returns codes like "k3m9p-x2q7d" and their hashes.
+func generateRecoveryCodes() ([]string, []string, error) {
+	const alphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 10)
+		for j := range b {
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+			if err != nil {
+				return nil, nil, err
+			}
+			b[j] = alphabet[n.Int64()]
+		}
+		codes[i] = string(b[:5]) + "-" + string(b[5:])
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode ignores case, spaces and dashes so codes can be typed loosely.
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -11,19 +11,37 @@ import (
 	"USDT_BackEnd/models"
 	"USDT_BackEnd/repository"
 
-	"github.com/dgrijalva/jwt-go"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type UserService struct {
-	repo   *repository.UserRepository
-	config *config.Config
+	repo      *repository.UserRepository
+	sessions  *repository.SessionRepository
+	settings  *repository.SettingsRepository
+	apiKeys   *repository.APIKeyRepository
+	exports   *repository.DataExportRepository
+	keys      *KeyManager
+	throttle  *Throttle
+	providers map[string]IdentityProvider
+	linkEmail map[string]bool // providers from EmailLinkingProviders
+	config    *config.Config
 }
 
-func NewUserService(cfg *config.Config) *UserService {
-	return &UserService{repo: &repository.UserRepository{}, config: cfg}
+func NewUserService(cfg *config.Config, keys *KeyManager) *UserService {
+	return &UserService{
+		repo:      &repository.UserRepository{},
+		sessions:  &repository.SessionRepository{},
+		settings:  &repository.SettingsRepository{},
+		apiKeys:   &repository.APIKeyRepository{},
+		exports:   &repository.DataExportRepository{},
+		keys:      keys,
+		throttle:  NewThrottle(),
+		providers: NewIdentityProviders(cfg),
+		linkEmail: EmailLinkingProviders(cfg),
+		config:    cfg,
+	}
 }
 
 // Register new user
@@ -33,6 +51,10 @@ func (s *UserService) Register(ctx context.Context, email, password string) (*mo
 	if email == "" || password == "" {
 		return nil, errors.New("email and password are required")
 	}
+	if err := ValidatePassword(password, email); err != nil {
+		log.Println("[DEBUG] Register: password rejected for:", email, "error:", err)
+		return nil, err
+	}
 
 	existing, err := s.repo.GetUserByEmail(ctx, email)
 	if err == nil && existing != nil {
@@ -66,52 +88,60 @@ func (s *UserService) Register(ctx context.Context, email, password string) (*mo
 		return nil, err
 	}
 
+	if err := s.sendVerificationOTP(ctx, email); err != nil {
+		log.Println("[ERROR] Failed to send verification OTP:", err)
+	}
+
 	log.Println("[DEBUG] User registered successfully:", email)
 	return user, nil
 }
 
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
 // Login
-// Login authenticates a user and returns JWT token
-func (s *UserService) Login(ctx context.Context, email, password string) (string, *models.User, error) {
+// Login authenticates a user and returns an access token plus a refresh token.
+// Every attempt is counted before the password is checked, and repeated failures
+// lock out the account and the client IP with exponential backoff.
+func (s *UserService) Login(ctx context.Context, email, password string, client ClientInfo) (*AuthTokens, *models.User, error) {
 	log.Println("[DEBUG] Login called for email:", email)
 
+	acctLimit, ipLimit := accountKey("login", email), ipKey("login", client.IP)
+	if err := s.throttle.Attempt(ctx, acctLimit, ipLimit); err != nil {
+		log.Println("[DEBUG] Login throttled for email:", email, "ip:", client.IP)
+		return nil, nil, err
+	}
+
 	// 1️⃣ Fetch user by email
 	user, err := s.repo.GetUserByEmail(ctx, email)
 	if err != nil {
 		if err.Error() == "mongo: no documents in result" {
 			log.Println("[DEBUG] User not found:", email)
-			return "", nil, errors.New("invalid credentials")
+			return nil, nil, ErrInvalidCredentials
 		}
 		log.Println("[ERROR] Failed to fetch user:", err)
-		return "", nil, err
+		return nil, nil, err
 	}
 	if user == nil {
 		log.Println("[DEBUG] User is nil for:", email)
-		return "", nil, errors.New("invalid credentials")
+		return nil, nil, ErrInvalidCredentials
 	}
 
 	// 2️⃣ Compare password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
 		log.Println("[DEBUG] Password mismatch for user:", email)
-		return "", nil, errors.New("invalid credentials")
-	}
-
-	// 3️⃣ Generate JWT token
-	tokenClaims := jwt.MapClaims{
-		"user_id": user.ID.Hex(), // ensures correct MongoDB ObjectID
-		"role":    user.Role,
-		"exp":     time.Now().Add(time.Hour * time.Duration(s.config.TokenExpiry)).Unix(),
+		return nil, nil, ErrInvalidCredentials
 	}
+	s.throttle.Succeed(ctx, acctLimit)
+	s.throttle.Uncount(ctx, ipLimit)
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, tokenClaims)
-	tokenString, err := token.SignedString([]byte(s.config.JWTSecret))
+	// 3️⃣ Start a session
+	tokens, user, err := s.issueTokens(ctx, user, client)
 	if err != nil {
-		log.Println("[ERROR] Failed to sign JWT:", err)
-		return "", nil, err
+		return nil, nil, err
 	}
 
 	log.Println("[DEBUG] Login successful for email:", email)
-	return tokenString, user, nil
+	return tokens, user, nil
 }
 
 // Change password
@@ -133,11 +163,23 @@ func (s *UserService) ChangePassword(ctx context.Context, userID primitive.Objec
 		return errors.New("current password incorrect")
 	}
 
-	hashed, _ := bcrypt.GenerateFromPassword([]byte(new), bcrypt.DefaultCost)
+	if err := ValidatePassword(new, user.Email); err != nil {
+		log.Println("[DEBUG] ChangePassword: new password rejected for userID:", userID.Hex(), "error:", err)
+		return err
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(new), bcrypt.DefaultCost)
+	if err != nil {
+		log.Println("[ERROR] Failed to hash password:", err)
+		return errors.New("failed to process password")
+	}
 	if err := s.repo.UpdatePassword(ctx, userID, string(hashed)); err != nil {
 		log.Println("[ERROR] Failed to update password:", err)
 		return err
 	}
+	if err := s.invalidateAllTokens(ctx, userID); err != nil {
+		return err
+	}
 
 	log.Println("[DEBUG] Password changed successfully for userID:", userID.Hex())
 	return nil
@@ -159,18 +201,13 @@ func (s *UserService) GetFavoritesByUserID(ctx context.Context, userID primitive
 		return []models.Word{}, nil
 	}
 
-	cursor, err := db.Database.Collection("words").Find(ctx, bson.M{"_id": bson.M{"$in": user.Favorites}})
+	// Words in the trash are left out
+	words, err := s.repo.GetFavorites(ctx, user.Favorites)
 	if err != nil {
 		log.Println("[ERROR] Failed to fetch favorite words:", err)
 		return nil, err
 	}
 
-	var words []models.Word
-	if err := cursor.All(ctx, &words); err != nil {
-		log.Println("[ERROR] Failed to decode favorite words:", err)
-		return nil, err
-	}
-
 	log.Println("[DEBUG] Favorites fetched for userID:", userID.Hex(), "count:", len(words))
 	return words, nil
 }
@@ -207,8 +244,9 @@ func (s *UserService) GetSubscribedUsers(ctx context.Context) ([]models.User, er
 func (s *UserService) GetUserByID(ctx context.Context, userID primitive.ObjectID) (*models.User, error) {
 	return s.repo.GetUserByID(ctx, userID)
 }
+
 // CheckAndDecrementSearches verifies the user has searches left and decrements.
-// Admin users bypass the check entirely.
+// Unverified accounts get no metered lookups. Admin users bypass the check entirely.
 func (s *UserService) CheckAndDecrementSearches(ctx context.Context, userID primitive.ObjectID) error {
 	user, err := s.repo.GetUserByID(ctx, userID)
 	if err != nil {
@@ -217,6 +255,9 @@ func (s *UserService) CheckAndDecrementSearches(ctx context.Context, userID prim
 	if user.Role == models.RoleAdmin {
 		return nil
 	}
+	if !user.EmailVerified {
+		return errors.New("EMAIL_NOT_VERIFIED")
+	}
 	if user.Subscription.SearchesLeft <= 0 {
 		return errors.New("SEARCH_LIMIT_REACHED")
 	}
@@ -236,6 +277,9 @@ func (s *UserService) HasSearchesLeft(ctx context.Context, userID primitive.Obje
 	if user.Role == models.RoleAdmin {
 		return nil
 	}
+	if !user.EmailVerified {
+		return errors.New("EMAIL_NOT_VERIFIED")
+	}
 	if user.Subscription.SearchesLeft <= 0 {
 		return errors.New("SEARCH_LIMIT_REACHED")
 	}
@@ -251,24 +295,3 @@ func (s *UserService) GetAllUsers(ctx context.Context, query string) ([]models.U
 func (s *UserService) UpdateSearchesLeft(ctx context.Context, userID primitive.ObjectID, count int) error {
 	return s.repo.UpdateSearchesLeft(ctx, userID, count)
 }
-
-// Delete user by ID (self-delete)
-func (s *UserService) DeleteUserByID(ctx context.Context, userID primitive.ObjectID) error {
-	log.Println("[DEBUG] DeleteUserByID called for userID:", userID.Hex())
-
-	// Check if user exists
-	_, err := s.repo.GetUserByID(ctx, userID)
-	if err != nil {
-		log.Println("[ERROR] User not found:", err)
-		return errors.New("user not found")
-	}
-
-	// Delete user
-	if err := s.repo.DeleteUserByID(ctx, userID); err != nil {
-		log.Println("[ERROR] Failed to delete user:", err)
-		return err
-	}
-
-	log.Println("[DEBUG] User deleted from DB:", userID.Hex())
-	return nil
-}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"USDT_BackEnd/models"
+	"USDT_BackEnd/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	MaxAudioBytes = 5 << 20 // largest accepted audio clip
+	maxAudioClips = 10      // clips per word
+)
+
+var (
+	ErrAudioNotFound      = errors.New("audio not found")
+	ErrInvalidAudio       = errors.New("audio must be an MP3, AAC/M4A, Ogg, WAV or WebM file")
+	ErrAudioTooLarge      = fmt.Errorf("audio must be at most %d MB", MaxAudioBytes>>20)
+	ErrTooManyAudio       = fmt.Errorf("a word can have at most %d audio clips", maxAudioClips)
+	ErrInvalidPitchAccent = errors.New("pitch accent must be between 0 and the number of morae in the reading")
+)
+
+// AddAudio validates a clip, stores it privately and attaches it to the word.
+func (s *WordService) AddAudio(ctx context.Context, idStr string, data []byte, label string) (*models.WordAudio, *models.Word, error) {
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return nil, nil, ErrWordNotFound
+	}
+	if len(data) > MaxAudioBytes {
+		return nil, nil, ErrAudioTooLarge
+	}
+	contentType, ext, ok := detectAudio(data)
+	if !ok {
+		return nil, nil, ErrInvalidAudio
+	}
+	word, err := s.repo.GetWordByID(ctx, id)
+	if err != nil {
+		return nil, nil, ErrWordNotFound
+	}
+	if len(word.Audio) >= maxAudioClips {
+		return nil, nil, ErrTooManyAudio
+	}
+
+	clip := models.WordAudio{
+		ID:          primitive.NewObjectID(),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Label:       strings.TrimSpace(label),
+		CreatedAt:   time.Now(),
+	}
+	clip.FileKey = fmt.Sprintf("words/audio/%s/%s.%s", id.Hex(), clip.ID.Hex(), ext)
+
+	storage := NewStorageService()
+	if err := storage.UploadPrivateFile(clip.FileKey, data, contentType); err != nil {
+		log.Println("[ERROR] Failed to upload audio for word:", idStr, "error:", err)
+		return nil, nil, err
+	}
+	after, err := s.repo.AddAudio(ctx, id, clip, maxAudioClips)
+	if err != nil {
+		if delErr := storage.DeleteFile(clip.FileKey); delErr != nil {
+			log.Println("[ERROR] Failed to delete unattached audio:", clip.FileKey, "error:", delErr)
+		}
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			// Deleted, or other uploads reached the limit, since the check above
+			if _, getErr := s.repo.GetWordByID(ctx, id); getErr != nil {
+				return nil, nil, ErrWordNotFound
+			}
+			return nil, nil, ErrTooManyAudio
+		}
+		return nil, nil, err
+	}
+	log.Println("[DEBUG] Audio", clip.ID.Hex(), "added to word", idStr)
+	return &clip, after, nil
+}
+
+// GetAudio returns a clip of a live word together with its content.
+func (s *WordService) GetAudio(ctx context.Context, idStr, audioIDStr string) (*models.WordAudio, []byte, time.Time, error) {
+	_, clip, err := s.findAudio(ctx, idStr, audioIDStr)
+	if err != nil {
+		return nil, nil, time.Time{}, err
+	}
+	data, modTime, err := NewStorageService().GetFile(clip.FileKey, MaxAudioBytes)
+	if err != nil {
+		log.Println("[ERROR] Failed to read audio:", clip.FileKey, "error:", err)
+		return nil, nil, time.Time{}, err
+	}
+	return clip, data, modTime, nil
+}
+
+// DeleteAudio removes a clip from the word and from storage, returning the word before and after.
+func (s *WordService) DeleteAudio(ctx context.Context, idStr, audioIDStr string) (before, after *models.Word, err error) {
+	before, clip, err := s.findAudio(ctx, idStr, audioIDStr)
+	if err != nil {
+		return nil, nil, err
+	}
+	after, err = s.repo.DeleteAudio(ctx, before.ID, clip.ID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil, ErrAudioNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	deleteAudioFiles([]string{clip.FileKey})
+	log.Println("[DEBUG] Audio", audioIDStr, "deleted from word", idStr)
+	return before, after, nil
+}
+
+// findAudio returns the live word and its clip with the given ID.
+func (s *WordService) findAudio(ctx context.Context, idStr, audioIDStr string) (*models.Word, *models.WordAudio, error) {
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return nil, nil, ErrWordNotFound
+	}
+	audioID, err := primitive.ObjectIDFromHex(audioIDStr)
+	if err != nil {
+		return nil, nil, ErrAudioNotFound
+	}
+	word, err := s.repo.GetWordByID(ctx, id)
+	if err != nil {
+		return nil, nil, ErrWordNotFound
+	}
+	i := slices.IndexFunc(word.Audio, func(a models.WordAudio) bool { return a.ID == audioID })
+	if i < 0 {
+		return nil, nil, ErrAudioNotFound
+	}
+	return word, &word.Audio[i], nil
+}
+
+// deleteAudioFiles removes audio clips from storage.
+func deleteAudioFiles(keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+	storage := NewStorageService()
+	for _, key := range keys {
+		if err := storage.DeleteFile(key); err != nil {
+			log.Println("[ERROR] Failed to delete word audio:", key, "error:", err)
+		}
+	}
+}
+
+// detectAudio identifies the accepted audio formats by their first bytes and
+// returns the content type and file extension to store the clip with.
+func detectAudio(data []byte) (contentType, ext string, ok bool) {
+	switch {
+	case len(data) < 12:
+		return "", "", false
+	case bytes.HasPrefix(data, []byte("ID3")):
+		return "audio/mpeg", "mp3", true
+	case data[0] == 0xFF && data[1]&0xF6 == 0xF0: // ADTS header
+		return "audio/aac", "aac", true
+	case data[0] == 0xFF && data[1]&0xE0 == 0xE0 && data[1]&0x06 != 0: // MPEG audio frame
+		return "audio/mpeg", "mp3", true
+	case bytes.HasPrefix(data, []byte("RIFF")) && string(data[8:12]) == "WAVE":
+		return "audio/wav", "wav", true
+	case bytes.HasPrefix(data, []byte("OggS")):
+		return "audio/ogg", "ogg", true
+	case string(data[4:8]) == "ftyp":
+		return "audio/mp4", "m4a", true
+	case bytes.HasPrefix(data, []byte{0x1A, 0x45, 0xDF, 0xA3}):
+		return "audio/webm", "webm", true
+	}
+	return "", "", false
+}
+
+// preparePitchAccent sorts and dedupes the accent patterns and checks each one
+// fits the primary reading.
+func preparePitchAccent(word *models.Word) error {
+	if len(word.PitchAccent) == 0 {
+		word.PitchAccent = nil
+		return nil
+	}
+	morae := utils.MoraCount(word.SubTerm)
+	for _, drop := range word.PitchAccent {
+		if drop < 0 || (word.SubTerm != "" && drop > morae) {
+			return ErrInvalidPitchAccent
+		}
+	}
+	slices.Sort(word.PitchAccent)
+	word.PitchAccent = slices.Compact(word.PitchAccent)
+	return nil
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"strings"
+
+	"USDT_BackEnd/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrExampleNotFound = errors.New("example not found")
+	ErrInvalidExample  = errors.New("an example needs Japanese text and an English or Myanmar translation")
+)
+
+// GetExamples returns the example sentences of a word.
+func (s *WordService) GetExamples(ctx context.Context, idStr string) ([]models.WordExample, error) {
+	word, err := s.GetWordByID(ctx, idStr)
+	if err != nil || word == nil {
+		return nil, ErrWordNotFound
+	}
+	if word.Examples == nil {
+		return []models.WordExample{}, nil
+	}
+	return word.Examples, nil
+}
+
+// AddExample appends an example sentence to a word. Like other edits, it is
+// recorded as a new revision.
+func (s *WordService) AddExample(ctx context.Context, idStr string, example models.WordExample, editor models.AuditActor) (*models.WordExample, *models.Word, error) {
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return nil, nil, ErrWordNotFound
+	}
+	if err := prepareExample(&example); err != nil {
+		return nil, nil, err
+	}
+	example.ID = primitive.NewObjectID()
+
+	if err := s.ensureBaseline(ctx, id); err != nil {
+		return nil, nil, err
+	}
+	word, err := s.repo.AddExample(ctx, id, example)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil, ErrWordNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	s.recordRevision(ctx, word, models.RevisionUpdate, editor, 0)
+	log.Println("[DEBUG] Example", example.ID.Hex(), "added to word", idStr)
+	return &example, word, nil
+}
+
+// UpdateExample replaces the text of one example and returns the word before and after.
+func (s *WordService) UpdateExample(ctx context.Context, idStr, exampleIDStr string, example models.WordExample, editor models.AuditActor) (before, after *models.Word, err error) {
+	id, exampleID, before, err := s.findExample(ctx, idStr, exampleIDStr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := prepareExample(&example); err != nil {
+		return nil, nil, err
+	}
+	example.ID = exampleID
+
+	if err := s.ensureBaseline(ctx, id); err != nil {
+		return nil, nil, err
+	}
+	after, err = s.repo.UpdateExample(ctx, id, example)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil, ErrExampleNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	s.recordRevision(ctx, after, models.RevisionUpdate, editor, 0)
+	log.Println("[DEBUG] Example", exampleIDStr, "updated on word", idStr)
+	return before, after, nil
+}
+
+// DeleteExample removes one example and returns the word before and after.
+func (s *WordService) DeleteExample(ctx context.Context, idStr, exampleIDStr string, editor models.AuditActor) (before, after *models.Word, err error) {
+	id, exampleID, before, err := s.findExample(ctx, idStr, exampleIDStr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.ensureBaseline(ctx, id); err != nil {
+		return nil, nil, err
+	}
+	after, err = s.repo.DeleteExample(ctx, id, exampleID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil, ErrExampleNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	s.recordRevision(ctx, after, models.RevisionUpdate, editor, 0)
+	log.Println("[DEBUG] Example", exampleIDStr, "deleted from word", idStr)
+	return before, after, nil
+}
+
+// findExample parses both IDs and returns the word, which must have the example.
+func (s *WordService) findExample(ctx context.Context, idStr, exampleIDStr string) (primitive.ObjectID, primitive.ObjectID, *models.Word, error) {
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return id, primitive.NilObjectID, nil, ErrWordNotFound
+	}
+	exampleID, err := primitive.ObjectIDFromHex(exampleIDStr)
+	if err != nil {
+		return id, exampleID, nil, ErrExampleNotFound
+	}
+	word, err := s.repo.GetWordByID(ctx, id)
+	if err != nil {
+		return id, exampleID, nil, ErrWordNotFound
+	}
+	if !slices.ContainsFunc(word.Examples, func(e models.WordExample) bool { return e.ID == exampleID }) {
+		return id, exampleID, nil, ErrExampleNotFound
+	}
+	return id, exampleID, word, nil
+}
+
+// prepareExample trims the example and checks it has text and a translation.
+func prepareExample(example *models.WordExample) error {
+	example.Japanese = strings.TrimSpace(example.Japanese)
+	example.Reading = strings.TrimSpace(example.Reading)
+	example.Myanmar = strings.TrimSpace(example.Myanmar)
+	example.English = strings.TrimSpace(example.English)
+	if example.Japanese == "" || (example.English == "" && example.Myanmar == "") {
+		return ErrInvalidExample
+	}
+	return nil
+}
+
+// prepareExamples validates the examples of a new word and gives each an ID.
+func prepareExamples(word *models.Word) error {
+	for i := range word.Examples {
+		if err := prepareExample(&word.Examples[i]); err != nil {
+			return err
+		}
+		word.Examples[i].ID = primitive.NewObjectID()
+	}
+	return nil
+}
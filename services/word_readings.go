@@ -0,0 +1,100 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"USDT_BackEnd/models"
+	"USDT_BackEnd/utils"
+)
+
+var (
+	ErrInvalidReading   = errors.New("each reading needs kana, and its type must be on, kun or empty")
+	ErrFuriganaMismatch = errors.New("furigana segments must spell out the Japanese text")
+)
+
+// keepReadings carries the stored readings, furigana and pitch accent over to an update from a
+// client that does not send them. A changed SubTerm replaces the old primary
+// reading, and furigana is rebuilt when the text or primary reading changed.
+func keepReadings(existing, word *models.Word) {
+	if word.Readings == nil {
+		for _, r := range existing.Readings {
+			if r.Kana != existing.SubTerm || word.SubTerm == existing.SubTerm {
+				word.Readings = append(word.Readings, r)
+			}
+		}
+	}
+	if word.Furigana == nil && word.Japanese == existing.Japanese && word.SubTerm == existing.SubTerm {
+		word.Furigana = existing.Furigana
+	}
+	// Pitch accent describes the primary reading, so it only carries over with it
+	if word.PitchAccent == nil && word.SubTerm == existing.SubTerm {
+		word.PitchAccent = existing.PitchAccent
+	}
+}
+
+// prepareReadings validates the readings and pitch accent and makes SubTerm the
+// first reading, then fills in furigana from Japanese and SubTerm unless the
+// client sent its own.
+func prepareReadings(word *models.Word) error {
+	word.SubTerm = strings.TrimSpace(word.SubTerm)
+
+	readings := make([]models.WordReading, 0, len(word.Readings)+1)
+	for _, r := range word.Readings {
+		r.Kana = strings.TrimSpace(r.Kana)
+		r.Type = strings.ToLower(strings.TrimSpace(r.Type))
+		if r.Kana == "" || (r.Type != "" && r.Type != models.ReadingOn && r.Type != models.ReadingKun) {
+			return ErrInvalidReading
+		}
+		if !slices.ContainsFunc(readings, func(o models.WordReading) bool { return o.Kana == r.Kana }) {
+			readings = append(readings, r)
+		}
+	}
+	if word.SubTerm == "" && len(readings) > 0 {
+		word.SubTerm = readings[0].Kana
+	}
+	if word.SubTerm != "" {
+		primary := models.WordReading{Kana: word.SubTerm}
+		if i := slices.IndexFunc(readings, func(r models.WordReading) bool { return r.Kana == word.SubTerm }); i >= 0 {
+			primary = readings[i]
+			readings = slices.Delete(readings, i, i+1)
+		}
+		readings = slices.Insert(readings, 0, primary)
+	}
+	word.Readings = nil
+	if len(readings) > 0 {
+		word.Readings = readings
+	}
+
+	if err := preparePitchAccent(word); err != nil {
+		return err
+	}
+
+	if word.Furigana == nil {
+		word.Furigana = FuriganaFor(word.Japanese, word.SubTerm)
+		return nil
+	}
+	var text strings.Builder
+	for _, seg := range word.Furigana {
+		text.WriteString(seg.Text)
+	}
+	if text.String() != word.Japanese {
+		return ErrFuriganaMismatch
+	}
+	return nil
+}
+
+// FuriganaFor splits japanese into ruby segments over reading. If the two cannot
+// be lined up, the whole reading goes over the whole word.
+func FuriganaFor(japanese, reading string) []models.FuriganaSegment {
+	segments, _ := utils.SegmentFurigana(japanese, reading)
+	if len(segments) == 0 {
+		return nil
+	}
+	furigana := make([]models.FuriganaSegment, len(segments))
+	for i, seg := range segments {
+		furigana[i] = models.FuriganaSegment{Text: seg.Text, Reading: seg.Reading}
+	}
+	return furigana
+}
@@ -0,0 +1,218 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"USDT_BackEnd/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrInvalidRelationType = errors.New("relation type must be one of " + strings.Join(models.RelationTypes, ", "))
+	ErrSelfRelation        = errors.New("a word cannot be related to itself")
+	ErrRelationExists      = errors.New("these words are already related")
+	ErrRelationNotFound    = errors.New("relation not found")
+)
+
+// RelatedWord is a word linked to another, with the relation as seen from that other word.
+type RelatedWord struct {
+	Type     string             `json:"type"`
+	ID       primitive.ObjectID `json:"id"`
+	Japanese string             `json:"japanese"`
+	SubTerm  string             `json:"subTerm"`
+	English  string             `json:"english"`
+	Myanmar  string             `json:"myanmar"`
+}
+
+// RelationLink is a relation named in an import. Target is the Japanese of the
+// related word, optionally followed by its reading in brackets, 開く(ひらく), to
+// pick one of several words written the same way. Source is used in problem messages.
+type RelationLink struct {
+	WordID primitive.ObjectID
+	Type   string
+	Target string
+	Source string
+}
+
+// GetRelatedWords returns the live words related to a word, grouped by type.
+// Words in the trash are left out until they are restored.
+func (s *WordService) GetRelatedWords(ctx context.Context, idStr string) ([]RelatedWord, error) {
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return nil, ErrWordNotFound
+	}
+	if _, err := s.repo.GetWordByID(ctx, id); err != nil {
+		return nil, ErrWordNotFound
+	}
+	relations, err := s.relations.ListForWord(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	types := make(map[primitive.ObjectID]string, len(relations))
+	ids := make([]primitive.ObjectID, 0, len(relations))
+	for i := range relations {
+		other, relationType := relations[i].Other(id)
+		types[other] = relationType
+		ids = append(ids, other)
+	}
+	related := []RelatedWord{}
+	if len(ids) == 0 {
+		return related, nil
+	}
+	words, err := s.repo.GetLiveWordsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range words {
+		related = append(related, RelatedWord{
+			Type:     types[w.ID],
+			ID:       w.ID,
+			Japanese: w.Japanese,
+			SubTerm:  w.SubTerm,
+			English:  w.English,
+			Myanmar:  w.Myanmar,
+		})
+	}
+	slices.SortStableFunc(related, func(a, b RelatedWord) int {
+		return slices.Index(models.RelationTypes, a.Type) - slices.Index(models.RelationTypes, b.Type)
+	})
+	return related, nil
+}
+
+// AddRelation links two live words: relatedIDStr is a relationType of idStr.
+func (s *WordService) AddRelation(ctx context.Context, idStr, relatedIDStr, relationType string, editor models.AuditActor) (*models.WordRelation, error) {
+	relationType = strings.ToLower(strings.TrimSpace(relationType))
+	if !slices.Contains(models.RelationTypes, relationType) {
+		return nil, ErrInvalidRelationType
+	}
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return nil, ErrWordNotFound
+	}
+	relatedID, err := primitive.ObjectIDFromHex(relatedIDStr)
+	if err != nil {
+		return nil, ErrWordNotFound
+	}
+	if id == relatedID {
+		return nil, ErrSelfRelation
+	}
+	words, err := s.repo.GetLiveWordsByIDs(ctx, []primitive.ObjectID{id, relatedID})
+	if err != nil {
+		return nil, err
+	}
+	if len(words) != 2 {
+		return nil, ErrWordNotFound
+	}
+	return s.createRelation(ctx, id, relatedID, relationType, editor)
+}
+
+// RemoveRelation unlinks two words and returns the removed relation.
+func (s *WordService) RemoveRelation(ctx context.Context, idStr, relatedIDStr string) (*models.WordRelation, error) {
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return nil, ErrRelationNotFound
+	}
+	relatedID, err := primitive.ObjectIDFromHex(relatedIDStr)
+	if err != nil {
+		return nil, ErrRelationNotFound
+	}
+	rel, err := s.relations.Get(ctx, id, relatedID)
+	if err != nil {
+		return nil, ErrRelationNotFound
+	}
+	deleted, err := s.relations.Delete(ctx, id, relatedID)
+	if err != nil {
+		return nil, err
+	}
+	if !deleted {
+		return nil, ErrRelationNotFound
+	}
+	log.Println("[DEBUG] Relation removed between", idStr, "and", relatedIDStr)
+	return rel, nil
+}
+
+// ImportRelations creates the relations named in an import. Links whose target
+// cannot be found, or matches several words, are skipped and described in
+// problems; links that already exist are skipped silently.
+func (s *WordService) ImportRelations(ctx context.Context, links []RelationLink, editor models.AuditActor) (created int, problems []string, err error) {
+	if len(links) == 0 {
+		return 0, nil, nil
+	}
+	texts := make([]string, 0, len(links))
+	for i := range links {
+		text, _ := splitRelationTarget(links[i].Target)
+		texts = append(texts, text)
+	}
+	slices.Sort(texts)
+	candidates, err := s.repo.FindLiveByJapanese(ctx, slices.Compact(texts))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	for _, link := range links {
+		relationType := strings.ToLower(strings.TrimSpace(link.Type))
+		if !slices.Contains(models.RelationTypes, relationType) {
+			problems = append(problems, fmt.Sprintf("%s: unknown relation type %q", link.Source, link.Type))
+			continue
+		}
+		text, reading := splitRelationTarget(link.Target)
+		var matches []primitive.ObjectID
+		for _, c := range candidates {
+			if c.Japanese == text && c.ID != link.WordID && (reading == "" || c.SubTerm == reading) {
+				matches = append(matches, c.ID)
+			}
+		}
+		switch len(matches) {
+		case 0:
+			problems = append(problems, fmt.Sprintf("%s: %s not found", link.Source, link.Target))
+			continue
+		case 1:
+		default:
+			problems = append(problems, fmt.Sprintf("%s: %s matches %d words, add the reading in brackets", link.Source, link.Target, len(matches)))
+			continue
+		}
+
+		if _, err := s.createRelation(ctx, link.WordID, matches[0], relationType, editor); err != nil {
+			if errors.Is(err, ErrRelationExists) {
+				continue
+			}
+			return created, problems, err
+		}
+		created++
+	}
+	return created, problems, nil
+}
+
+func (s *WordService) createRelation(ctx context.Context, id, relatedID primitive.ObjectID, relationType string, editor models.AuditActor) (*models.WordRelation, error) {
+	rel := models.NewWordRelation(id, relatedID, relationType)
+	rel.CreatedBy = editor
+	rel.CreatedAt = time.Now()
+	if err := s.relations.Create(ctx, &rel); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrRelationExists
+		}
+		return nil, err
+	}
+	log.Println("[DEBUG] Relation added:", relatedID.Hex(), "is", relationType, "of", id.Hex())
+	return &rel, nil
+}
+
+// splitRelationTarget splits "開く(ひらく)" or "開く（ひらく）" into text and reading.
+func splitRelationTarget(target string) (text, reading string) {
+	target = strings.TrimSpace(target)
+	for _, brackets := range [][2]string{{"(", ")"}, {"（", "）"}} {
+		if open := strings.Index(target, brackets[0]); open > 0 && strings.HasSuffix(target, brackets[1]) {
+			return strings.TrimSpace(target[:open]), strings.TrimSpace(target[open+len(brackets[0]) : len(target)-len(brackets[1])])
+		}
+	}
+	return target, ""
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"time"
+
+	"USDT_BackEnd/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// revisionInsertAttempts bounds retries when concurrent edits race for the next revision number.
+const revisionInsertAttempts = 3
+
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// WordHistoryEntry is one revision together with what it changed compared to the one before.
+type WordHistoryEntry struct {
+	models.WordRevision
+	Changes map[string]models.AuditChange `json:"changes"`
+}
+
+// GetWordHistory returns one page of a word's revisions, newest first, each with a
+// field-level diff against the previous revision.
+func (s *WordService) GetWordHistory(ctx context.Context, idStr string, page, limit int) ([]WordHistoryEntry, bool, int64, error) {
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return nil, false, 0, ErrWordNotFound
+	}
+	revisions, total, err := s.revisions.ListRevisions(ctx, id, page, limit)
+	if err != nil {
+		return nil, false, 0, err
+	}
+
+	count := len(revisions)
+	if count > limit {
+		count = limit // the extra revision is only there to diff against
+	}
+	entries := make([]WordHistoryEntry, count)
+	for i := 0; i < count; i++ {
+		var previous interface{}
+		if i+1 < len(revisions) {
+			previous = revisions[i+1].Snapshot
+		}
+		entries[i] = WordHistoryEntry{
+			WordRevision: revisions[i],
+			Changes:      diffDocuments(previous, revisions[i].Snapshot),
+		}
+	}
+	return entries, int64(page*limit) < total, total, nil
+}
+
+// RevertWord restores the content saved in an earlier revision. The revert is
+// itself recorded as a new revision, so it can be undone the same way.
+func (s *WordService) RevertWord(ctx context.Context, idStr string, revision int, editor models.AuditActor) (before, after *models.Word, err error) {
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return nil, nil, ErrWordNotFound
+	}
+	before, err = s.repo.GetWordByID(ctx, id)
+	if err != nil {
+		return nil, nil, ErrWordNotFound
+	}
+	target, err := s.revisions.GetRevision(ctx, id, revision)
+	if err != nil {
+		return nil, nil, ErrRevisionNotFound
+	}
+
+	snap := target.Snapshot
+	if len(snap.Senses) == 0 {
+		// Saved before senses existed: rebuild them from the flat fields.
+		legacy := models.Word{English: snap.English, Myanmar: snap.Myanmar}
+		legacy.NormalizeSenses()
+		snap.Senses = legacy.Senses
+	}
+	if snap.Readings == nil && snap.Furigana == nil {
+		// Saved before readings existed: derive them from SubTerm.
+		legacy := models.Word{Japanese: snap.Japanese, SubTerm: snap.SubTerm}
+		if err := prepareReadings(&legacy); err == nil {
+			snap.Readings, snap.Furigana = legacy.Readings, legacy.Furigana
+		}
+	}
+	if !snap.DictionaryID.IsZero() && checkDictionariesExist(ctx, s.dictionaries, []primitive.ObjectID{snap.DictionaryID}) != nil {
+		// The dictionary has been deleted since: keep the word where it is.
+		snap.DictionaryID = primitive.NilObjectID
+	}
+	after, err = s.repo.RestoreSnapshot(ctx, id, snap)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.recordRevision(ctx, after, models.RevisionRevert, editor, revision)
+	log.Println("[DEBUG] Word", idStr, "reverted to revision", revision)
+	return before, after, nil
+}
+
+// wordImageURLs lists every image the word has used, including ones kept only
+// by its history, so they can be removed from storage with the word.
+func (s *WordService) wordImageURLs(ctx context.Context, word *models.Word) ([]string, error) {
+	urls, err := s.revisions.ImageURLs(ctx, word.ID)
+	if err != nil {
+		return nil, err
+	}
+	if word.ImageURL != "" && !slices.Contains(urls, word.ImageURL) {
+		urls = append(urls, word.ImageURL)
+	}
+	return urls, nil
+}
+
+// ensureBaseline records the current state of a word that has no history yet
+// (created before revisions existed, or bulk imported) so its first edit can be undone.
+func (s *WordService) ensureBaseline(ctx context.Context, id primitive.ObjectID) error {
+	latest, err := s.revisions.LatestRevision(ctx, id)
+	if err != nil {
+		return err
+	}
+	if latest != nil {
+		return nil
+	}
+	word, err := s.repo.GetWordByID(ctx, id)
+	if err != nil {
+		return ErrWordNotFound
+	}
+	rev := &models.WordRevision{
+		WordID:    id,
+		Revision:  1,
+		Action:    models.RevisionBaseline,
+		Snapshot:  word.Snapshot(),
+		CreatedAt: word.UpdatedAt,
+	}
+	if err := s.revisions.Insert(ctx, rev); err != nil && !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+	return nil
+}
+
+// recordRevision stores the word's new state under the next revision number. Like
+// audit logging, a failure is logged rather than returned: the edit itself has been saved.
+func (s *WordService) recordRevision(ctx context.Context, word *models.Word, action string, editor models.AuditActor, revertedFrom int) {
+	for attempt := 0; attempt < revisionInsertAttempts; attempt++ {
+		latest, err := s.revisions.LatestRevision(ctx, word.ID)
+		if err != nil {
+			log.Println("[ERROR] Failed to read revisions for word:", word.ID.Hex(), "error:", err)
+			return
+		}
+		next := 1
+		if latest != nil {
+			next = latest.Revision + 1
+		}
+
+		err = s.revisions.Insert(ctx, &models.WordRevision{
+			WordID:       word.ID,
+			Revision:     next,
+			Action:       action,
+			Snapshot:     word.Snapshot(),
+			Editor:       editor,
+			RevertedFrom: revertedFrom,
+			CreatedAt:    time.Now(),
+		})
+		if err == nil {
+			return
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			log.Println("[ERROR] Failed to record revision for word:", word.ID.Hex(), "error:", err)
+			return
+		}
+	}
+	log.Println("[ERROR] Gave up recording revision for word:", word.ID.Hex(), "after concurrent edits")
+}
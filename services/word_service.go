@@ -3,33 +3,89 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
 
 	"USDT_BackEnd/models"
 	"USDT_BackEnd/repository"
 	"USDT_BackEnd/utils"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrWordNotFound        = errors.New("word not found")
+	ErrInvalidPartOfSpeech = errors.New("unknown part of speech")
+	ErrEmptySense          = errors.New("each sense needs an English or Myanmar gloss")
+	ErrInvalidJLPTLevel    = errors.New("JLPT level must be one of N5, N4, N3, N2, N1")
+	ErrUnknownTag          = errors.New("unknown tag")
 )
 
 type WordService struct {
-	repo *repository.WordRepository
+	repo         *repository.WordRepository
+	revisions    *repository.WordRevisionRepository
+	users        *repository.UserRepository
+	tags         *repository.TagRepository
+	relations    *repository.WordRelationRepository
+	dictionaries *repository.DictionaryRepository
 }
 
 func NewWordService() *WordService {
-	return &WordService{repo: &repository.WordRepository{}}
+	return &WordService{
+		repo:         &repository.WordRepository{},
+		revisions:    &repository.WordRevisionRepository{},
+		users:        &repository.UserRepository{},
+		tags:         &repository.TagRepository{},
+		relations:    &repository.WordRelationRepository{},
+		dictionaries: &repository.DictionaryRepository{},
+	}
 }
 
-func (s *WordService) SearchWords(ctx context.Context, query string) ([]models.Word, error) {
-	if query == "" {
-		return nil, errors.New("query cannot be empty")
+// NewWordFilter normalizes the tag, JLPT level and dictionaries query parameters.
+// dictionaries is a comma-separated list of dictionary IDs; empty or "all" means
+// every dictionary.
+func NewWordFilter(tag, jlptLevel, dictionaries string) (repository.WordFilter, error) {
+	f := repository.WordFilter{
+		Tag:       strings.ToLower(strings.TrimSpace(tag)),
+		JLPTLevel: strings.ToUpper(strings.TrimSpace(jlptLevel)),
+	}
+	if f.JLPTLevel != "" && !slices.Contains(models.JLPTLevels, f.JLPTLevel) {
+		return f, ErrInvalidJLPTLevel
+	}
+	if strings.TrimSpace(dictionaries) != "all" {
+		ids, err := parseDictionaryIDs(strings.Split(dictionaries, ","))
+		if err != nil {
+			return f, err
+		}
+		if len(ids) > 0 {
+			f.Dictionaries = ids
+		}
 	}
-	var kanaQueries []string
-	if utils.IsRomaji(query) {
-		hiragana := utils.RomajiToHiragana(query)
-		katakana := utils.HiraganaToKatakana(hiragana)
-		kanaQueries = []string{hiragana, katakana}
+	return f, nil
+}
+
+// DefaultSearchDictionaries returns the dictionaries the user searches when a
+// search names none. Nil means all of them, also for callers that are not a user.
+func (s *WordService) DefaultSearchDictionaries(ctx context.Context, userID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	user, err := s.users.GetUserByID(ctx, userID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
 	}
-	return s.repo.SearchWords(ctx, query, kanaQueries)
+	return user.SearchDictionaries, nil
+}
+
+// SearchWords matches query against every text field. With a tag or JLPT filter
+// the query may be empty, listing the words in that category.
+func (s *WordService) SearchWords(ctx context.Context, query string, filter repository.WordFilter) ([]models.Word, error) {
+	if query == "" && filter.IsEmpty() {
+		return nil, errors.New("query cannot be empty")
+	}
+	return s.repo.SearchWords(ctx, query, kanaVariants(query), filter)
 }
 
 func (s *WordService) GetWordByID(ctx context.Context, idStr string) (*models.Word, error) {
@@ -40,45 +96,160 @@ func (s *WordService) GetWordByID(ctx context.Context, idStr string) (*models.Wo
 	return s.repo.GetWordByID(ctx, id)
 }
 func (s *WordService) BulkCreateWords(ctx context.Context, words []models.Word) (int, error) {
+	// Imports usually go to one dictionary, so each is looked up once.
+	resolved := map[primitive.ObjectID]primitive.ObjectID{}
+	for i := range words {
+		given := words[i].DictionaryID
+		if id, ok := resolved[given]; ok {
+			words[i].DictionaryID = id
+			continue
+		}
+		if err := s.prepareDictionary(ctx, &words[i]); err != nil {
+			return 0, err
+		}
+		resolved[given] = words[i].DictionaryID
+	}
+
+	var names []string
+	for i := range words {
+		if err := prepareCategories(&words[i]); err != nil {
+			return 0, fmt.Errorf("word %d (%s): %w", i+1, words[i].Japanese, err)
+		}
+		names = append(names, words[i].Tags...)
+	}
+	slices.Sort(names)
+	if err := s.checkTagsExist(ctx, slices.Compact(names)); err != nil {
+		return 0, err
+	}
+	for i := range words {
+		if err := prepareReadings(&words[i]); err != nil {
+			return 0, fmt.Errorf("word %d (%s): %w", i+1, words[i].Japanese, err)
+		}
+		if err := prepareSenses(&words[i]); err != nil {
+			return 0, fmt.Errorf("word %d (%s): %w", i+1, words[i].Japanese, err)
+		}
+		if err := prepareExamples(&words[i]); err != nil {
+			return 0, fmt.Errorf("word %d (%s): %w", i+1, words[i].Japanese, err)
+		}
+	}
 	return s.repo.BulkInsert(ctx, words)
 }
 
-func (s *WordService) GetAllWords(ctx context.Context, page, limit int, query string) ([]models.Word, bool, int64, error) {
-	var kanaQueries []string
-	if utils.IsRomaji(query) {
-		hiragana := utils.RomajiToHiragana(query)
-		katakana := utils.HiraganaToKatakana(hiragana)
-		kanaQueries = []string{hiragana, katakana}
+func (s *WordService) GetAllWords(ctx context.Context, page, limit int, query string, filter repository.WordFilter) ([]models.Word, bool, int64, error) {
+	return s.repo.GetAllWords(ctx, page, limit, query, kanaVariants(query), filter)
+}
+
+// EachWord calls fn for every word GetAllWords would list, for exports.
+func (s *WordService) EachWord(ctx context.Context, query string, filter repository.WordFilter, fn func(*models.Word) error) error {
+	return s.repo.EachWord(ctx, query, kanaVariants(query), filter, fn)
+}
+
+// kanaVariants returns the hiragana and katakana spellings of a romaji query.
+func kanaVariants(query string) []string {
+	if !utils.IsRomaji(query) {
+		return nil
 	}
-	return s.repo.GetAllWords(ctx, page, limit, query, kanaQueries)
+	hiragana := utils.RomajiToHiragana(query)
+	return []string{hiragana, utils.HiraganaToKatakana(hiragana)}
 }
 
-func (s *WordService) CreateWord(ctx context.Context, word *models.Word) error {
-	return s.repo.CreateWord(ctx, word)
+func (s *WordService) CreateWord(ctx context.Context, word *models.Word, editor models.AuditActor) error {
+	if err := prepareReadings(word); err != nil {
+		return err
+	}
+	if err := prepareSenses(word); err != nil {
+		return err
+	}
+	if err := prepareExamples(word); err != nil {
+		return err
+	}
+	if err := s.prepareTags(ctx, word); err != nil {
+		return err
+	}
+	if err := s.prepareDictionary(ctx, word); err != nil {
+		return err
+	}
+	if err := s.repo.CreateWord(ctx, word); err != nil {
+		return err
+	}
+	s.recordRevision(ctx, word, models.RevisionCreate, editor, 0)
+	return nil
 }
 
-func (s *WordService) UpdateWord(ctx context.Context, idStr string, word *models.Word) error {
+// UpdateWord saves the new content and returns the stored word. When word has no
+// senses the caller only knows the flat fields; see keepSenses. Readings and
+// furigana left nil are kept the same way; see keepReadings. A word without a
+// dictionary stays in the one it is in.
+func (s *WordService) UpdateWord(ctx context.Context, idStr string, word *models.Word, editor models.AuditActor) (*models.Word, error) {
 	id, err := primitive.ObjectIDFromHex(idStr)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	existing, err := s.repo.GetWordByID(ctx, id)
+	if err != nil {
+		return nil, ErrWordNotFound
+	}
+	keepReadings(existing, word)
+	if err := prepareReadings(word); err != nil {
+		return nil, err
+	}
+	if word.Senses == nil {
+		keepSenses(existing, word)
+	}
+	if err := prepareSenses(word); err != nil {
+		return nil, err
 	}
-	return s.repo.UpdateWord(ctx, id, word)
+	if err := s.prepareTags(ctx, word); err != nil {
+		return nil, err
+	}
+	if word.DictionaryID.IsZero() {
+		word.DictionaryID = existing.DictionaryID
+	}
+	if err := s.prepareDictionary(ctx, word); err != nil {
+		return nil, err
+	}
+	if err := s.ensureBaseline(ctx, id); err != nil {
+		return nil, err
+	}
+	updated, err := s.repo.UpdateWord(ctx, id, word)
+	if err != nil {
+		return nil, err
+	}
+	s.recordRevision(ctx, updated, models.RevisionUpdate, editor, 0)
+	return updated, nil
 }
 
+// DeleteWord moves the word to the trash. Its image, history and favorite
+// references are kept until the trash purge removes it for good.
 func (s *WordService) DeleteWord(ctx context.Context, idStr string) error {
 	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return ErrWordNotFound
+	}
+	deleted, err := s.repo.DeleteWord(ctx, id)
 	if err != nil {
 		return err
 	}
-	return s.repo.DeleteWord(ctx, id)
+	if !deleted {
+		return ErrWordNotFound
+	}
+	return nil
 }
 
-func (s *WordService) SetWordIgnore(ctx context.Context, idStr string, ignore bool) error {
+func (s *WordService) SetWordIgnore(ctx context.Context, idStr string, ignore bool, editor models.AuditActor) error {
 	id, err := primitive.ObjectIDFromHex(idStr)
 	if err != nil {
 		return err
 	}
-	return s.repo.SetWordIgnore(ctx, id, ignore)
+	if err := s.ensureBaseline(ctx, id); err != nil {
+		return err
+	}
+	updated, err := s.repo.SetWordIgnore(ctx, id, ignore)
+	if err != nil {
+		return err
+	}
+	s.recordRevision(ctx, updated, models.RevisionIgnore, editor, 0)
+	return nil
 }
 
 func (s *WordService) GetDuplicateWords(ctx context.Context, page, limit int, query string) ([]interface{}, int64, error) {
@@ -92,3 +263,92 @@ func (s *WordService) GetDuplicateWords(ctx context.Context, page, limit int, qu
 	}
 	return out, total, nil
 }
+
+// keepSenses carries the stored senses over to an update from a client that only
+// sends the flat English and Myanmar fields. Unchanged flat fields keep every
+// sense; a changed gloss on a single-sense word keeps its part of speech and notes.
+// Editing the flat view of a multi-sense word replaces its senses with one.
+func keepSenses(existing, word *models.Word) {
+	if word.English == existing.English && word.Myanmar == existing.Myanmar {
+		word.Senses = existing.Senses
+		return
+	}
+	if len(existing.Senses) == 1 {
+		sense := existing.Senses[0]
+		sense.English, sense.Myanmar = word.English, word.Myanmar
+		word.Senses = []models.WordSense{sense}
+	}
+}
+
+// prepareSenses trims and validates the senses, then syncs the flat fields.
+func prepareSenses(word *models.Word) error {
+	for i := range word.Senses {
+		sense := &word.Senses[i]
+		sense.PartOfSpeech = strings.ToLower(strings.TrimSpace(sense.PartOfSpeech))
+		sense.English = strings.TrimSpace(sense.English)
+		sense.Myanmar = strings.TrimSpace(sense.Myanmar)
+		sense.Notes = strings.TrimSpace(sense.Notes)
+		if sense.PartOfSpeech != "" && !slices.Contains(models.PartsOfSpeech, sense.PartOfSpeech) {
+			return fmt.Errorf("%w %q", ErrInvalidPartOfSpeech, sense.PartOfSpeech)
+		}
+		if sense.English == "" && sense.Myanmar == "" {
+			return ErrEmptySense
+		}
+	}
+	word.NormalizeSenses()
+	return nil
+}
+
+// prepareDictionary files a word without a dictionary in the default one and
+// checks that any other dictionary exists.
+func (s *WordService) prepareDictionary(ctx context.Context, word *models.Word) error {
+	if word.DictionaryID.IsZero() {
+		dictionary, err := s.dictionaries.GetDefault(ctx)
+		if err != nil {
+			return fmt.Errorf("default dictionary: %w", err)
+		}
+		word.DictionaryID = dictionary.ID
+		return nil
+	}
+	return checkDictionariesExist(ctx, s.dictionaries, []primitive.ObjectID{word.DictionaryID})
+}
+
+// prepareTags normalizes the word's tags and JLPT level and checks every tag exists.
+func (s *WordService) prepareTags(ctx context.Context, word *models.Word) error {
+	if err := prepareCategories(word); err != nil {
+		return err
+	}
+	return s.checkTagsExist(ctx, word.Tags)
+}
+
+// prepareCategories lowercases and dedupes the tags and validates the JLPT level.
+func prepareCategories(word *models.Word) error {
+	tags := make([]string, 0, len(word.Tags))
+	for _, tag := range word.Tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	word.Tags = nil
+	if len(tags) > 0 {
+		word.Tags = tags
+	}
+
+	word.JLPTLevel = strings.ToUpper(strings.TrimSpace(word.JLPTLevel))
+	if word.JLPTLevel != "" && !slices.Contains(models.JLPTLevels, word.JLPTLevel) {
+		return ErrInvalidJLPTLevel
+	}
+	return nil
+}
+
+func (s *WordService) checkTagsExist(ctx context.Context, names []string) error {
+	missing, err := s.tags.MissingNames(ctx, names)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrUnknownTag, strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"USDT_BackEnd/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// trashPurgeBatch is how many expired words one purge pass handles at most.
+const trashPurgeBatch = 200
+
+// RestoreWord takes a word out of the trash.
+func (s *WordService) RestoreWord(ctx context.Context, idStr string) error {
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return ErrWordNotFound
+	}
+	restored, err := s.repo.RestoreWord(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !restored {
+		return ErrWordNotFound
+	}
+	log.Println("[DEBUG] Word restored from trash:", idStr)
+	return nil
+}
+
+// GetTrash returns one page of trashed words, most recently deleted first.
+func (s *WordService) GetTrash(ctx context.Context, page, limit int) ([]models.Word, bool, int64, error) {
+	return s.repo.GetTrash(ctx, page, limit)
+}
+
+// StartTrashPurge permanently removes words that have been in the trash for
+// longer than retentionDays, checking once an hour.
+func (s *WordService) StartTrashPurge(retentionDays int) {
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+			cutoff := time.Now().AddDate(0, 0, -retentionDays)
+			if err := s.purgeTrash(ctx, cutoff); err != nil {
+				log.Println("[ERROR] Word trash purge failed:", err)
+			}
+			cancel()
+			<-ticker.C
+		}
+	}()
+}
+
+// purgeTrash deletes words trashed before cutoff, then their images, audio,
+// revision history, relations and the favorite references pointing at them.
+func (s *WordService) purgeTrash(ctx context.Context, cutoff time.Time) error {
+	for {
+		words, err := s.repo.GetTrashedBefore(ctx, cutoff, trashPurgeBatch)
+		if err != nil {
+			return err
+		}
+		if len(words) == 0 {
+			return nil
+		}
+
+		var purged []primitive.ObjectID
+		var images, audio []string
+		for _, word := range words {
+			urls, err := s.wordImageURLs(ctx, &word)
+			if err != nil {
+				return err
+			}
+			ok, err := s.repo.PurgeWord(ctx, word.ID)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue // restored since it was listed
+			}
+			if err := s.revisions.DeleteByWord(ctx, word.ID); err != nil {
+				log.Println("[ERROR] Failed to delete revisions of purged word:", word.ID.Hex(), "error:", err)
+			}
+			purged = append(purged, word.ID)
+			images = append(images, urls...)
+			for _, clip := range word.Audio {
+				audio = append(audio, clip.FileKey)
+			}
+		}
+
+		if len(purged) > 0 {
+			if err := s.users.RemoveWordsFromFavorites(ctx, purged); err != nil {
+				return err
+			}
+			if err := s.relations.DeleteForWords(ctx, purged); err != nil {
+				return err
+			}
+		}
+		deleteWordImages(images)
+		deleteAudioFiles(audio)
+		log.Println("[DEBUG] Purged", len(purged), "words from the trash")
+
+		if len(words) < trashPurgeBatch {
+			return nil
+		}
+	}
+}
+
+// deleteWordImages removes images uploaded under words/ from storage.
+func deleteWordImages(urls []string) {
+	if len(urls) == 0 {
+		return
+	}
+	storage := NewStorageService()
+	for _, url := range urls {
+		fileName := url[strings.LastIndex(url, "/")+1:]
+		if err := storage.DeleteFile("words/" + fileName); err != nil {
+			log.Println("[ERROR] Failed to delete word image:", fileName, "error:", err)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// RubySegment is one part of a Japanese word and the kana written above it.
+// Reading is empty for parts that are already kana.
+type RubySegment struct {
+	Text    string
+	Reading string
+}
+
+// KatakanaToHiragana converts every katakana rune (U+30A1–U+30F6) to its
+// hiragana equivalent (U+3041–U+3096). Other characters are unchanged.
+func KatakanaToHiragana(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= 0x30A1 && r <= 0x30F6 {
+			b.WriteRune(r - 0x60)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// IsKanji reports whether r is written with a reading above it: a Han character,
+// or one of the marks used like one (々 repeats the kanji before it, ヶ in 一ヶ月).
+func IsKanji(r rune) bool {
+	return unicode.Is(unicode.Han, r) || r == '々' || r == '〆' || r == 'ヶ'
+}
+
+// SegmentFurigana splits word into runs of kanji and kana and lines them up with
+// reading, so each kanji run gets the part of the reading above it: 食べ物 read
+// たべもの gives 食/た, べ, 物/もの. Kana are compared ignoring hiragana/katakana.
+// When the kana in word do not appear in reading, it returns the whole word with
+// the whole reading and false.
+func SegmentFurigana(word, reading string) ([]RubySegment, bool) {
+	if word == "" {
+		return nil, true
+	}
+
+	type run struct {
+		text  string
+		kanji bool
+	}
+	var runs []run
+	hasKanji := false
+	for _, r := range word {
+		kanji := IsKanji(r)
+		hasKanji = hasKanji || kanji
+		if n := len(runs); n > 0 && runs[n-1].kanji == kanji {
+			runs[n-1].text += string(r)
+		} else {
+			runs = append(runs, run{text: string(r), kanji: kanji})
+		}
+	}
+	if !hasKanji {
+		return []RubySegment{{Text: word}}, true
+	}
+	whole := []RubySegment{{Text: word, Reading: reading}}
+	if reading == "" {
+		return whole, false
+	}
+
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	for _, r := range runs {
+		if r.kanji {
+			pattern.WriteString("(.+?)")
+		} else {
+			pattern.WriteString("(" + regexp.QuoteMeta(KatakanaToHiragana(r.text)) + ")")
+		}
+	}
+	pattern.WriteString("$")
+	re, err := regexp.Compile(pattern.String())
+	if err != nil {
+		return whole, false
+	}
+
+	// Katakana and hiragana are both three bytes in UTF-8, so indexes into the
+	// converted reading are also indexes into the original.
+	m := re.FindStringSubmatchIndex(KatakanaToHiragana(reading))
+	if m == nil {
+		return whole, false
+	}
+	segments := make([]RubySegment, len(runs))
+	for i, r := range runs {
+		segments[i] = RubySegment{Text: r.text}
+		if r.kanji {
+			segments[i].Reading = reading[m[2*i+2]:m[2*i+3]]
+		}
+	}
+	return segments, true
+}
+
+// MoraCount returns the number of morae in a kana reading. Small ゃゅょ and
+// vowels combine with the kana before them; っ, ん and ー each count as one.
+func MoraCount(kana string) int {
+	count := 0
+	for _, r := range KatakanaToHiragana(kana) {
+		switch r {
+		case 'ゃ', 'ゅ', 'ょ', 'ぁ', 'ぃ', 'ぅ', 'ぇ', 'ぉ', 'ゎ':
+			continue
+		}
+		if (r >= 0x3041 && r <= 0x3096) || r == 'ー' {
+			count++
+		}
+	}
+	return count
+}